@@ -0,0 +1,230 @@
+package actionlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	css "challenge/client"
+	"challenge/config"
+	"challenge/logic"
+)
+
+// Format identifies an action log file format.
+type Format string
+
+// Supported action log formats.
+const (
+	FormatJSONL    Format = "jsonl"    // One enriched record per line.
+	FormatCSV      Format = "csv"      // Enriched records with a header row.
+	FormatSolution Format = "solution" // The exact JSON payload posted by Client.Solve.
+)
+
+// csvHeader is the header row written to and expected from CSV files.
+var csvHeader = []string{"timestamp", "id", "action", "temperature", "source", "target", "storage", "freshness", "decay", "rule", "reason", "operator"}
+
+// Record is the serializable form of an action.
+type Record struct {
+	Timestamp   int64   `json:"timestamp"`             // unix timestamp in microseconds
+	ID          string  `json:"id"`                    // order id
+	Action      string  `json:"action"`                // place, move, pickup or discard
+	Temperature string  `json:"temperature,omitempty"` // order temperature
+	Source      string  `json:"source,omitempty"`      // storage unit the order left
+	Target      string  `json:"target,omitempty"`      // storage unit the order entered
+	Storage     string  `json:"storage"`               // storage unit name
+	Freshness   float64 `json:"freshness"`             // remaining freshness in seconds
+	Decay       float64 `json:"decay,omitempty"`       // freshness decay rate after the action, relative to normal
+	Rule        string  `json:"rule,omitempty"`        // rule that fired
+	Reason      string  `json:"reason,omitempty"`      // operator's reason
+	Operator    string  `json:"operator,omitempty"`    // operator who ordered the action, if any
+
+	Decision *DecisionRecord `json:"decision,omitempty"` // orders considered, if traced
+}
+
+// DecisionRecord is the serializable form of the decision behind an action.
+type DecisionRecord struct {
+	Candidates []CandidateRecord `json:"candidates"`
+}
+
+// CandidateRecord is an order considered for an action.
+type CandidateRecord struct {
+	ID        string  `json:"id"`        // order id
+	Storage   string  `json:"storage"`   // storage unit name
+	Freshness float64 `json:"freshness"` // remaining freshness in seconds
+}
+
+// NewRecord converts an action into its serializable form.
+func NewRecord(a logic.Action) Record {
+	return Record{
+		Timestamp:   a.Timestamp,
+		ID:          a.OrderID,
+		Action:      a.Action,
+		Temperature: a.Temperature,
+		Source:      a.Source,
+		Target:      a.Target,
+		Storage:     a.Storage,
+		Freshness:   a.Freshness.Seconds(),
+		Decay:       a.Decay,
+		Rule:        a.Rule,
+		Reason:      a.Reason,
+		Operator:    a.Operator,
+		Decision:    newDecisionRecord(a.Decision),
+	}
+}
+
+func newDecisionRecord(d *logic.Decision) *DecisionRecord {
+	if d == nil {
+		return nil
+	}
+	r := &DecisionRecord{}
+	for _, c := range d.Candidates {
+		r.Candidates = append(r.Candidates, CandidateRecord{ID: c.OrderID, Storage: c.Storage, Freshness: c.Freshness.Seconds()})
+	}
+	return r
+}
+
+// ToAction converts the record back into an action.
+func (r Record) ToAction() logic.Action {
+	return logic.Action{
+		Timestamp:   r.Timestamp,
+		OrderID:     r.ID,
+		Action:      r.Action,
+		Temperature: r.Temperature,
+		Source:      r.Source,
+		Target:      r.Target,
+		Storage:     r.Storage,
+		Freshness:   time.Duration(r.Freshness * float64(time.Second)),
+		Decay:       r.Decay,
+		Rule:        r.Rule,
+		Reason:      r.Reason,
+		Operator:    r.Operator,
+		Decision:    r.Decision.toDecision(),
+	}
+}
+
+func (r *DecisionRecord) toDecision() *logic.Decision {
+	if r == nil {
+		return nil
+	}
+	d := &logic.Decision{}
+	for _, c := range r.Candidates {
+		d.Candidates = append(d.Candidates, logic.Candidate{OrderID: c.ID, Storage: c.Storage, Freshness: time.Duration(c.Freshness * float64(time.Second))})
+	}
+	return d
+}
+
+// ParseFormat validates a format name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatJSONL, FormatCSV, FormatSolution:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown action log format %q", name)
+}
+
+// FormatFromPath infers the format from a file extension; ".json" means a solution payload.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".json":
+		return FormatSolution
+	}
+	return FormatJSONL
+}
+
+// ToClientActions converts internal actions to the challenge client's action format,
+// leaving out operator annotations the server does not know.
+func ToClientActions(actions []logic.Action) []css.Action {
+	var out []css.Action
+	for _, a := range actions {
+		if a.Action == config.ACTION_TYPE_PIN || a.Action == config.ACTION_TYPE_UNPIN {
+			continue
+		}
+		out = append(out, css.Action{
+			Timestamp: a.Timestamp,
+			ID:        a.OrderID,
+			Action:    a.Action,
+		})
+	}
+	return out
+}
+
+// WriteJSONL writes one enriched record per line.
+func WriteJSONL(w io.Writer, actions []logic.Action) error {
+	enc := json.NewEncoder(w)
+	for _, a := range actions {
+		if err := enc.Encode(NewRecord(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes enriched records with a header row. Decisions are left out; use JSONL
+// to export them.
+func WriteCSV(w io.Writer, actions []logic.Action) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, a := range actions {
+		r := NewRecord(a)
+		row := []string{
+			strconv.FormatInt(r.Timestamp, 10),
+			r.ID,
+			r.Action,
+			r.Temperature,
+			r.Source,
+			r.Target,
+			r.Storage,
+			strconv.FormatFloat(r.Freshness, 'f', 3, 64),
+			strconv.FormatFloat(r.Decay, 'f', -1, 64),
+			r.Rule,
+			r.Reason,
+			r.Operator,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSolution writes the same payload Client.Solve posts for these actions and timing parameters.
+func WriteSolution(w io.Writer, actions []logic.Action, rate, min, max time.Duration) error {
+	return json.NewEncoder(w).Encode(css.NewSolution(rate, min, max, ToClientActions(actions)))
+}
+
+// Write writes actions in the given format.
+func Write(w io.Writer, format Format, actions []logic.Action, rate, min, max time.Duration) error {
+	switch format {
+	case FormatJSONL:
+		return WriteJSONL(w, actions)
+	case FormatCSV:
+		return WriteCSV(w, actions)
+	case FormatSolution:
+		return WriteSolution(w, actions, rate, min, max)
+	}
+	return fmt.Errorf("unknown action log format %q", format)
+}
+
+// ExportFile writes actions to a file in the given format.
+func ExportFile(path string, format Format, actions []logic.Action, rate, min, max time.Duration) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, format, actions, rate, min, max); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %v: %v", path, err)
+	}
+	return f.Close()
+}
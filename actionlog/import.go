@@ -0,0 +1,141 @@
+package actionlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	css "challenge/client"
+	"challenge/logic"
+)
+
+// ReadJSONL reads enriched records written by WriteJSONL. Blank lines are skipped.
+func ReadJSONL(r io.Reader) ([]logic.Action, error) {
+	var actions []logic.Action
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		actions = append(actions, rec.ToAction())
+	}
+	return actions, scanner.Err()
+}
+
+// ReadCSV reads enriched records written by WriteCSV.
+func ReadCSV(r io.Reader) ([]logic.Action, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	if len(rows[0]) != len(csvHeader) {
+		return nil, fmt.Errorf("unexpected csv header %v", rows[0])
+	}
+	for i, name := range rows[0] {
+		if name != csvHeader[i] {
+			return nil, fmt.Errorf("unexpected csv header %v", rows[0])
+		}
+	}
+	var actions []logic.Action
+	for i, row := range rows[1:] {
+		if len(row) != len(csvHeader) {
+			return nil, fmt.Errorf("row %d: expected %d fields, got %d", i+2, len(csvHeader), len(row))
+		}
+		ts, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid timestamp: %v", i+2, err)
+		}
+		freshness, err := strconv.ParseFloat(row[7], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid freshness: %v", i+2, err)
+		}
+		decay, err := strconv.ParseFloat(row[8], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid decay: %v", i+2, err)
+		}
+		rec := Record{
+			Timestamp:   ts,
+			ID:          row[1],
+			Action:      row[2],
+			Temperature: row[3],
+			Source:      row[4],
+			Target:      row[5],
+			Storage:     row[6],
+			Freshness:   freshness,
+			Decay:       decay,
+			Rule:        row[9],
+			Reason:      row[10],
+			Operator:    row[11],
+		}
+		actions = append(actions, rec.ToAction())
+	}
+	return actions, nil
+}
+
+// ReadSolution reads a payload written by WriteSolution.
+func ReadSolution(r io.Reader) (css.Solution, error) {
+	var sol css.Solution
+	if err := json.NewDecoder(r).Decode(&sol); err != nil {
+		return css.Solution{}, err
+	}
+	return sol, nil
+}
+
+// Read reads actions in the given format. Solution payloads carry no storage or freshness data.
+func Read(r io.Reader, format Format) ([]logic.Action, error) {
+	switch format {
+	case FormatJSONL:
+		return ReadJSONL(r)
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatSolution:
+		sol, err := ReadSolution(r)
+		if err != nil {
+			return nil, err
+		}
+		var actions []logic.Action
+		for _, a := range sol.Actions {
+			actions = append(actions, logic.Action{Timestamp: a.Timestamp, OrderID: a.ID, Action: a.Action})
+		}
+		return actions, nil
+	}
+	return nil, fmt.Errorf("unknown action log format %q", format)
+}
+
+// ImportFile loads actions from a file, inferring the format from its extension.
+func ImportFile(path string) ([]logic.Action, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	actions, err := Read(f, FormatFromPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %v", path, err)
+	}
+	return actions, nil
+}
+
+// SolutionOptions converts a solution's timing options back into durations.
+func SolutionOptions(sol css.Solution) (rate, min, max time.Duration) {
+	return time.Duration(sol.Options.Rate) * time.Microsecond,
+		time.Duration(sol.Options.Min) * time.Microsecond,
+		time.Duration(sol.Options.Max) * time.Microsecond
+}
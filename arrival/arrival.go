@@ -0,0 +1,183 @@
+package arrival
+
+import (
+	"challenge/config"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Model names.
+const (
+	ModelConstant = "constant" // Fixed interval between orders.
+	ModelPoisson  = "poisson"  // Poisson process with a constant rate.
+	ModelCurve    = "curve"    // Poisson process whose rate follows the time of day.
+	ModelBurst    = "burst"    // Poisson process with periodic bursts of a higher rate.
+	ModelTrace    = "trace"    // Replay of recorded arrival times.
+)
+
+// Model is an order arrival process.
+type Model interface {
+	// Start begins a sequence of arrivals, drawing randomness from rng.
+	Start(rng *rand.Rand) Sequence
+	String() string
+}
+
+// Sequence returns the arrival time of the next order as an offset from the start of the
+// run. Offsets never decrease. A Sequence is not safe for concurrent use.
+type Sequence func() time.Duration
+
+// seedMask separates the arrival stream of a run from its other random streams.
+const seedMask = 0x5851f42d4c957f2d
+
+// Seed derives the seed of a run's arrivals from the run's seed, so that they do not
+// repeat the draws of other random streams seeded the same way.
+func Seed(seed int64) int64 {
+	return seed ^ seedMask
+}
+
+// Constant places orders at a fixed interval, the first one at the start of the run.
+type Constant struct {
+	Interval time.Duration
+}
+
+func (m Constant) Start(*rand.Rand) Sequence {
+	next := time.Duration(0)
+	return func() time.Duration {
+		at := next
+		next += m.Interval
+		return at
+	}
+}
+
+func (m Constant) String() string {
+	return fmt.Sprintf("%s(%v)", ModelConstant, m.Interval)
+}
+
+// Poisson places orders at exponentially distributed intervals.
+type Poisson struct {
+	Rate float64 // Orders per second.
+}
+
+func (m Poisson) Start(rng *rand.Rand) Sequence {
+	return thinned(func(float64) float64 { return m.Rate }, m.Rate, rng)
+}
+
+func (m Poisson) String() string {
+	return fmt.Sprintf("%s(%g/s)", ModelPoisson, m.Rate)
+}
+
+// Curve is a Poisson process whose rate changes through the day, such as a lunch rush.
+type Curve struct {
+	Points    []config.RatePoint // Orders per second at hours of the day, sorted by hour.
+	StartHour float64            // Hour of the day at which the run starts.
+}
+
+// RateAt returns the arrival rate t seconds into the run.
+func (m Curve) RateAt(t float64) float64 {
+	n := len(m.Points)
+	if n == 1 {
+		return m.Points[0].Rate
+	}
+	hour := math.Mod(m.StartHour+t/3600, 24)
+	i := sort.Search(n, func(i int) bool { return m.Points[i].Hour > hour })
+	prev, next := m.Points[(i+n-1)%n], m.Points[i%n]
+	span := math.Mod(next.Hour-prev.Hour+24, 24)
+	if span == 0 {
+		return prev.Rate
+	}
+	return prev.Rate + (next.Rate-prev.Rate)*math.Mod(hour-prev.Hour+24, 24)/span
+}
+
+func (m Curve) Start(rng *rand.Rand) Sequence {
+	peak := 0.0
+	for _, p := range m.Points {
+		peak = max(peak, p.Rate)
+	}
+	return thinned(m.RateAt, peak, rng)
+}
+
+func (m Curve) String() string {
+	return fmt.Sprintf("%s(%d points from %gh)", ModelCurve, len(m.Points), m.StartHour)
+}
+
+// Burst is a Poisson process that switches to a higher rate for a while at regular
+// intervals, starting with a burst.
+type Burst struct {
+	Rate      float64       // Orders per second between bursts.
+	BurstRate float64       // Orders per second during a burst.
+	Every     time.Duration // Time between the starts of consecutive bursts.
+	Length    time.Duration // Duration of each burst.
+}
+
+// RateAt returns the arrival rate t seconds into the run.
+func (m Burst) RateAt(t float64) float64 {
+	if math.Mod(t, m.Every.Seconds()) < m.Length.Seconds() {
+		return m.BurstRate
+	}
+	return m.Rate
+}
+
+func (m Burst) Start(rng *rand.Rand) Sequence {
+	return thinned(m.RateAt, max(m.Rate, m.BurstRate), rng)
+}
+
+func (m Burst) String() string {
+	return fmt.Sprintf("%s(%g/s, %g/s for %v every %v)", ModelBurst, m.Rate, m.BurstRate, m.Length, m.Every)
+}
+
+// Trace replays recorded arrivals. Orders beyond the end of the recording keep arriving
+// at its average interval.
+type Trace struct {
+	Offsets []time.Duration // Arrival offsets from the start of the recording, sorted.
+}
+
+// NewTrace builds a trace from recorded arrival times, relative to the earliest one.
+func NewTrace(times []time.Time) Trace {
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	offsets := make([]time.Duration, len(sorted))
+	for i, t := range sorted {
+		offsets[i] = t.Sub(sorted[0])
+	}
+	return Trace{Offsets: offsets}
+}
+
+func (m Trace) Start(*rand.Rand) Sequence {
+	var interval time.Duration
+	if n := len(m.Offsets); n > 1 {
+		interval = m.Offsets[n-1] / time.Duration(n-1)
+	}
+	i := 0
+	return func() time.Duration {
+		defer func() { i++ }()
+		if i < len(m.Offsets) {
+			return m.Offsets[i]
+		}
+		var last time.Duration
+		if len(m.Offsets) > 0 {
+			last = m.Offsets[len(m.Offsets)-1]
+		}
+		return last + time.Duration(i-len(m.Offsets)+1)*interval
+	}
+}
+
+func (m Trace) String() string {
+	return fmt.Sprintf("%s(%d arrivals)", ModelTrace, len(m.Offsets))
+}
+
+// thinned samples a Poisson process with a time-varying rate (orders per second at t
+// seconds into the run) by thinning a process of the peak rate.
+func thinned(rate func(t float64) float64, peak float64, rng *rand.Rand) Sequence {
+	t := 0.0
+	return func() time.Duration {
+		for {
+			t += rng.ExpFloat64() / peak
+			if rng.Float64()*peak < rate(t) {
+				return time.Duration(t * float64(time.Second))
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package arrival
+
+import (
+	"bufio"
+	"challenge/config"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromConfig builds the arrival model described by a configuration. Constant arrivals
+// use the given interval, which is also used when c is nil.
+func FromConfig(c *config.ArrivalConfig, interval time.Duration) (Model, error) {
+	if c == nil {
+		return Constant{Interval: interval}, nil
+	}
+	switch c.Model {
+	case "", ModelConstant:
+		return Constant{Interval: interval}, nil
+	case ModelPoisson:
+		if c.Rate <= 0 {
+			return nil, fmt.Errorf("%s arrivals need a positive rate", c.Model)
+		}
+		return Poisson{Rate: c.Rate}, nil
+	case ModelCurve:
+		if len(c.Curve) == 0 {
+			return nil, fmt.Errorf("%s arrivals need at least one rate point", c.Model)
+		}
+		points := append([]config.RatePoint(nil), c.Curve...)
+		peak := 0.0
+		for i, p := range points {
+			if p.Hour < 0 || p.Hour >= 24 || p.Rate < 0 {
+				return nil, fmt.Errorf("invalid rate point %d: %g/s at hour %g", i, p.Rate, p.Hour)
+			}
+			peak = max(peak, p.Rate)
+		}
+		if peak == 0 {
+			return nil, fmt.Errorf("%s arrivals need a positive rate at some hour", c.Model)
+		}
+		sort.Slice(points, func(i, j int) bool { return points[i].Hour < points[j].Hour })
+		return Curve{Points: points, StartHour: c.StartHour}, nil
+	case ModelBurst:
+		if c.Rate < 0 || c.BurstRate <= 0 || c.BurstEveryS <= 0 || c.BurstLengthS <= 0 {
+			return nil, fmt.Errorf("%s arrivals need a non-negative rate and a positive burst rate, period and length", c.Model)
+		}
+		return Burst{
+			Rate:      c.Rate,
+			BurstRate: c.BurstRate,
+			Every:     seconds(c.BurstEveryS),
+			Length:    seconds(c.BurstLengthS),
+		}, nil
+	case ModelTrace:
+		if c.Trace == "" {
+			return nil, fmt.Errorf("%s arrivals need a trace file", c.Model)
+		}
+		return LoadTrace(c.Trace)
+	}
+	return nil, fmt.Errorf("unknown arrival model %q (want %s, %s, %s, %s or %s)",
+		c.Model, ModelConstant, ModelPoisson, ModelCurve, ModelBurst, ModelTrace)
+}
+
+// ParseSpec parses an arrival model from a command-line value of the form
+// "model[:key=value,...]", with the keys of the JSON configuration, for example
+// "poisson:rate=2" or "burst:rate=1,burst_rate=6,burst_every_s=60,burst_length_s=10".
+// Curve points are written as hour@rate separated by semicolons, for example
+// "curve:curve=0@0.2;12@3;14@0.5;19@2,start_hour=11".
+func ParseSpec(spec string) (*config.ArrivalConfig, error) {
+	model, params, _ := strings.Cut(spec, ":")
+	c := &config.ArrivalConfig{Model: strings.TrimSpace(model)}
+	if strings.TrimSpace(params) == "" {
+		return c, nil
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid arrival parameter %q, want key=value", param)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "rate":
+			c.Rate, err = strconv.ParseFloat(value, 64)
+		case "burst_rate":
+			c.BurstRate, err = strconv.ParseFloat(value, 64)
+		case "burst_every_s":
+			c.BurstEveryS, err = strconv.ParseFloat(value, 64)
+		case "burst_length_s":
+			c.BurstLengthS, err = strconv.ParseFloat(value, 64)
+		case "start_hour":
+			c.StartHour, err = strconv.ParseFloat(value, 64)
+		case "trace":
+			c.Trace = value
+		case "curve":
+			c.Curve, err = parseCurve(value)
+		default:
+			return nil, fmt.Errorf("unknown arrival parameter %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid arrival parameter %s: %v", key, err)
+		}
+	}
+	return c, nil
+}
+
+func parseCurve(value string) ([]config.RatePoint, error) {
+	var points []config.RatePoint
+	for _, item := range strings.Split(value, ";") {
+		hour, rate, ok := strings.Cut(item, "@")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate point %q, want hour@rate", item)
+		}
+		var p config.RatePoint
+		var err error
+		if p.Hour, err = strconv.ParseFloat(strings.TrimSpace(hour), 64); err != nil {
+			return nil, err
+		}
+		if p.Rate, err = strconv.ParseFloat(strings.TrimSpace(rate), 64); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// LoadTrace reads recorded arrival times, one per line, as RFC 3339 timestamps or Unix
+// timestamps in microseconds (the unit of the action log). Blank lines and lines
+// starting with # are ignored.
+func LoadTrace(path string) (Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Trace{}, err
+	}
+	defer f.Close()
+
+	var times []time.Time
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if micros, err := strconv.ParseInt(text, 10, 64); err == nil {
+			times = append(times, time.UnixMicro(micros))
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return Trace{}, fmt.Errorf("%v:%d: invalid timestamp %q", path, line, text)
+		}
+		times = append(times, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return Trace{}, err
+	}
+	if len(times) == 0 {
+		return Trace{}, fmt.Errorf("%v: no arrival times", path)
+	}
+	return NewTrace(times), nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"challenge/config"
+	"challenge/sim"
+)
+
+// runBench implements the bench command: a tournament of placement and discard strategies
+// across seeds and storage configurations, simulated on a virtual clock.
+func runBench(args []string) {
+	cmd := flag.NewFlagSet("bench", flag.ExitOnError)
+	ordersPath := cmd.String("orders", "", "Path to a JSON or JSONL file of orders (required)")
+	seeds := cmd.Int("seeds", 10, "Number of seeds per strategy and configuration")
+	firstSeed := cmd.Int64("seed", 1, "First seed; seeds are consecutive from here")
+	strategies := cmd.String("strategies", "", "Comma-separated placement/discard strategies (all combinations if blank)")
+	configs := cmd.String("configs", "config/init.json", "Comma-separated storage configuration files")
+	orderRate := cmd.Duration("rate", 500*time.Millisecond, "Inverse order rate (time between order placements)")
+	minPickup := cmd.Duration("min", 4*time.Second, "Minimum pickup time")
+	maxPickup := cmd.Duration("max", 8*time.Second, "Maximum pickup time")
+	arrivalSpec := cmd.String("arrival", "", "Arrival model, e.g. poisson:rate=2 (constant --rate if blank)")
+	parallel := cmd.Int("parallel", runtime.NumCPU(), "Number of simulations to run concurrently")
+	out := cmd.String("out", "", "Write the ranking as JSON to this file (optional)")
+	verbose := cmd.Bool("verbose", false, "Keep the fulfillment system's log output")
+	cmd.Parse(args)
+
+	if *ordersPath == "" {
+		log.Fatalf("bench: --orders is required")
+	}
+	orders, stays, err := loadWorkload(*ordersPath)
+	if err != nil {
+		log.Fatalf("bench: %v", err)
+	}
+
+	arrivals, err := arrivalModel(*arrivalSpec, nil, *orderRate)
+	if err != nil {
+		log.Fatalf("bench: %v", err)
+	}
+
+	spec := sim.BenchSpec{
+		Orders:   orders,
+		Rate:     *orderRate,
+		Arrivals: arrivals,
+		Min:      *minPickup,
+		Max:      *maxPickup,
+		Stays:    stays,
+		Parallel: *parallel,
+	}
+	for i := 0; i < *seeds; i++ {
+		spec.Seeds = append(spec.Seeds, *firstSeed+int64(i))
+	}
+	if *strategies == "" {
+		spec.Strategies = sim.AllStrategies()
+	} else {
+		for _, name := range splitList(*strategies) {
+			s, err := sim.ParseStrategy(name)
+			if err != nil {
+				log.Fatalf("bench: %v", err)
+			}
+			spec.Strategies = append(spec.Strategies, s)
+		}
+	}
+	for _, path := range splitList(*configs) {
+		cfg, err := config.ReadConfig(path)
+		if err != nil {
+			log.Fatalf("bench: %v", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		spec.Configs = append(spec.Configs, sim.Variant{Name: name, Config: cfg})
+	}
+
+	log.Printf("Running %d orders x %d seeds x %d strategies x %d configurations",
+		len(spec.Orders), len(spec.Seeds), len(spec.Strategies), len(spec.Configs))
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	start := time.Now()
+	entries := sim.Bench(spec)
+	log.SetOutput(os.Stderr)
+	log.Printf("Finished in %v", time.Since(start).Round(time.Millisecond))
+
+	sim.PrintRanking(os.Stdout, entries)
+	if *out != "" {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			log.Fatalf("bench: %v", err)
+		}
+		if err := os.WriteFile(*out, data, 0644); err != nil {
+			log.Fatalf("bench: %v", err)
+		}
+		fmt.Printf("Ranking written to %s\n", *out)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping blank items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,146 @@
+package catalog
+
+import (
+	css "challenge/client"
+	"challenge/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Item is a food on the menu.
+type Item struct {
+	Name         string  `json:"name"`
+	Temp         string  `json:"temp"`                    // Ideal temperature: hot, cold or room.
+	Freshness    int     `json:"freshness,omitempty"`     // Default freshness of an order, in seconds.
+	FreshnessMin int     `json:"freshness_min,omitempty"` // Shortest freshness of an order, in seconds.
+	FreshnessMax int     `json:"freshness_max,omitempty"` // Longest freshness of an order, in seconds.
+	Size         int     `json:"size,omitempty"`          // Storage slots an order takes up.
+	Value        float64 `json:"value,omitempty"`         // Menu price.
+	Weight       float64 `json:"weight,omitempty"`        // Relative popularity.
+}
+
+// FreshnessRange returns the shortest and longest freshness of an order in seconds. An
+// item without a range only comes with its default freshness.
+func (it Item) FreshnessRange() (int, int) {
+	if it.FreshnessMin == 0 && it.FreshnessMax == 0 {
+		return it.Freshness, it.Freshness
+	}
+	return it.FreshnessMin, it.FreshnessMax
+}
+
+// DefaultFreshness returns the freshness given to orders that do not specify one: the
+// item's default freshness, or the middle of its range.
+func (it Item) DefaultFreshness() int {
+	if it.Freshness > 0 {
+		return it.Freshness
+	}
+	return (it.FreshnessMin + it.FreshnessMax) / 2
+}
+
+// Catalog is the set of foods orders are drawn from.
+type Catalog struct {
+	Items []Item `json:"items"`
+}
+
+// Load reads a catalog from a JSON file and validates it.
+func Load(path string) (Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Catalog{}, err
+	}
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Catalog{}, fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return Catalog{}, fmt.Errorf("%v: %v", path, err)
+	}
+	return c, nil
+}
+
+// Validate checks that the catalog is non-empty and every item is well formed.
+func (c Catalog) Validate() error {
+	if len(c.Items) == 0 {
+		return fmt.Errorf("catalog has no items")
+	}
+	seen := make(map[string]bool)
+	for _, it := range c.Items {
+		switch {
+		case it.Name == "":
+			return fmt.Errorf("catalog item without a name")
+		case seen[it.Name]:
+			return fmt.Errorf("duplicate catalog item %q", it.Name)
+		case it.Temp != config.TEMP_TYPE_HOT && it.Temp != config.TEMP_TYPE_COLD && it.Temp != config.TEMP_TYPE_ROOM:
+			return fmt.Errorf("item %q has unknown temperature %q", it.Name, it.Temp)
+		case it.Freshness < 0:
+			return fmt.Errorf("item %q has negative freshness %d", it.Name, it.Freshness)
+		case it.Size < 0 || it.Value < 0 || it.Weight < 0:
+			return fmt.Errorf("item %q has a negative size, value or weight", it.Name)
+		}
+		lo, hi := it.FreshnessRange()
+		if lo <= 0 || hi < lo {
+			return fmt.Errorf("item %q has invalid freshness range [%d, %d]", it.Name, lo, hi)
+		}
+		if it.Freshness > 0 && (it.Freshness < lo || it.Freshness > hi) {
+			return fmt.Errorf("item %q has default freshness %d outside its range [%d, %d]", it.Name, it.Freshness, lo, hi)
+		}
+		seen[it.Name] = true
+	}
+	return nil
+}
+
+// Item returns the item with the given name.
+func (c Catalog) Item(name string) (Item, bool) {
+	for _, it := range c.Items {
+		if it.Name == name {
+			return it, true
+		}
+	}
+	return Item{}, false
+}
+
+// Fill completes an incoming order with the defaults of its menu item (temperature and
+// freshness, when missing) and checks it against the item. The error lists every way the
+// order disagrees with the menu; the filled order is returned either way, keeping the
+// order's own values where they conflict.
+func (c Catalog) Fill(o css.Order) (css.Order, Item, error) {
+	item, ok := c.Item(o.Name)
+	if !ok {
+		return o, Item{}, fmt.Errorf("unknown menu item %q", o.Name)
+	}
+	var problems []string
+	if o.Temp == "" {
+		o.Temp = item.Temp
+	} else if o.Temp != item.Temp {
+		problems = append(problems, fmt.Sprintf("temperature %s, menu says %s", o.Temp, item.Temp))
+	}
+	if o.Freshness == 0 {
+		o.Freshness = item.DefaultFreshness()
+	} else if lo, hi := item.FreshnessRange(); o.Freshness < lo || o.Freshness > hi {
+		problems = append(problems, fmt.Sprintf("freshness %ds outside the menu range [%d, %d]", o.Freshness, lo, hi))
+	}
+	if len(problems) > 0 {
+		return o, item, fmt.Errorf("%s: %s", o.Name, strings.Join(problems, "; "))
+	}
+	return o, item, nil
+}
+
+// Default returns a small built-in menu with a mix of hot, cold and room-temperature food.
+func Default() Catalog {
+	return Catalog{Items: []Item{
+		{Name: "Cheese Pizza", Temp: config.TEMP_TYPE_HOT, FreshnessMin: 120, FreshnessMax: 300, Size: 2, Value: 14, Weight: 6},
+		{Name: "Pepperoni Pizza", Temp: config.TEMP_TYPE_HOT, FreshnessMin: 120, FreshnessMax: 300, Size: 2, Value: 16, Weight: 5},
+		{Name: "Spicy Ramen", Temp: config.TEMP_TYPE_HOT, FreshnessMin: 60, FreshnessMax: 150, Size: 1, Value: 12.5, Weight: 3},
+		{Name: "Chicken Burrito", Temp: config.TEMP_TYPE_HOT, FreshnessMin: 90, FreshnessMax: 240, Size: 1, Value: 9.5, Weight: 4},
+		{Name: "French Fries", Temp: config.TEMP_TYPE_HOT, FreshnessMin: 30, FreshnessMax: 90, Size: 1, Value: 3.5, Weight: 4},
+		{Name: "Cobb Salad", Temp: config.TEMP_TYPE_COLD, FreshnessMin: 120, FreshnessMax: 300, Size: 1, Value: 11, Weight: 3},
+		{Name: "Salmon Sushi", Temp: config.TEMP_TYPE_COLD, FreshnessMin: 60, FreshnessMax: 180, Size: 1, Value: 15, Weight: 3},
+		{Name: "Vanilla Ice Cream", Temp: config.TEMP_TYPE_COLD, FreshnessMin: 30, FreshnessMax: 90, Size: 1, Value: 5, Weight: 2},
+		{Name: "Iced Coffee", Temp: config.TEMP_TYPE_COLD, FreshnessMin: 90, FreshnessMax: 240, Size: 1, Value: 4.5, Weight: 3},
+		{Name: "Potato Chips", Temp: config.TEMP_TYPE_ROOM, FreshnessMin: 240, FreshnessMax: 600, Size: 1, Value: 2, Weight: 2},
+		{Name: "Chocolate Cookies", Temp: config.TEMP_TYPE_ROOM, FreshnessMin: 180, FreshnessMax: 480, Size: 1, Value: 3, Weight: 2},
+		{Name: "Banana", Temp: config.TEMP_TYPE_ROOM, FreshnessMin: 300, FreshnessMax: 600, Size: 1, Value: 1, Weight: 1},
+	}}
+}
@@ -34,17 +34,31 @@ type Action struct {
 	Action    string `json:"action"`    // place, move, pickup or discard
 }
 
-type options struct {
+// Options is a json-friendly representation of the harness timing parameters.
+type Options struct {
 	Rate int64 `json:"rate"` // inverse rate in microseconds
 	Min  int64 `json:"min"`  // min pickup in microseconds
 	Max  int64 `json:"max"`  // max pickup in microseconds
 }
 
-type solution struct {
-	Options options  `json:"options"`
+// Solution is the payload submitted to the server by Solve.
+type Solution struct {
+	Options Options  `json:"options"`
 	Actions []Action `json:"actions"`
 }
 
+// NewSolution builds the payload for a sequence of actions and timing parameters.
+func NewSolution(rate, min, max time.Duration, actions []Action) Solution {
+	return Solution{
+		Options: Options{
+			Rate: rate.Microseconds(),
+			Min:  min.Microseconds(),
+			Max:  max.Microseconds(),
+		},
+		Actions: actions,
+	}
+}
+
 // Client is a client for fetching and solving challenge test problems.
 type Client struct {
 	endpoint, auth string
@@ -90,14 +104,7 @@ func (c *Client) New(name string, seed int64) (string, []Order, error) {
 func (c *Client) Solve(id string, rate, min, max time.Duration, actions []Action) (string, error) {
 	url := fmt.Sprintf("%v/solve?auth=%v", c.endpoint, c.auth)
 
-	payload := solution{
-		Options: options{
-			Rate: rate.Microseconds(),
-			Min:  min.Microseconds(),
-			Max:  max.Microseconds(),
-		},
-		Actions: actions,
-	}
+	payload := NewSolution(rate, min, max, actions)
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", err
@@ -0,0 +1,63 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. The fulfillment system reads time only through a Clock,
+// so simulations can run on virtual time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Stepped is a clock that only moves when it is set, such as Virtual. Code that waits on
+// a Stepped clock moves it to the instant it waits for instead of sleeping.
+type Stepped interface {
+	Clock
+	Set(t time.Time)
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+// Now returns the current wall-clock time.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Virtual is a manually advanced clock.
+type Virtual struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewVirtual creates a virtual clock starting at the given time.
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+// Now returns the current virtual time.
+func (v *Virtual) Now() time.Time {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.now
+}
+
+// Set moves the clock to t. Moving backwards is ignored so time stays monotonic.
+func (v *Virtual) Set(t time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if t.After(v.now) {
+		v.now = t
+	}
+}
+
+// Advance moves the clock forward by d.
+func (v *Virtual) Advance(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if d > 0 {
+		v.now = v.now.Add(d)
+	}
+}
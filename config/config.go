@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -17,6 +18,119 @@ type FulfillmentConfig struct {
 	HeaterCap  int `json:"heater_cap"`
 	NumShelves int `json:"num_shelves"`
 	ShelfCap   int `json:"shelf_cap"`
+
+	// Individually configured units. A group with a unit list ignores its count and
+	// capacity above.
+	Coolers []UnitConfig `json:"coolers,omitempty"`
+	Heaters []UnitConfig `json:"heaters,omitempty"`
+	Shelves []UnitConfig `json:"shelves,omitempty"`
+
+	// How a group picks the unit receiving an order: first-fit (default), best-fit or least-loaded.
+	FitStrategy string `json:"fit_strategy,omitempty"`
+
+	// Strategy configuration; blank selects the default policy.
+	PlacementPolicy string `json:"placement_policy,omitempty"`
+	DiscardPolicy   string `json:"discard_policy,omitempty"`
+	RescuePriority  string `json:"rescue_priority,omitempty"`
+
+	// Limits on automatic moves of an order; zero disables each. A move must also gain at
+	// least the move cost factor times the order's remaining lifetime in freshness.
+	MaxMovesPerOrder int     `json:"max_moves_per_order,omitempty"`
+	MinDwellMs       int     `json:"min_dwell_ms,omitempty"`
+	MoveCostFactor   float64 `json:"move_cost_factor,omitempty"`
+
+	// Record the decision behind every action: the rule that fired and the orders considered.
+	TraceDecisions bool `json:"trace_decisions,omitempty"`
+
+	// Invariant checking: after every operation in debug mode and/or periodically while
+	// running the harness. The mode is "report" (default), "panic" or "off".
+	DebugInvariants     bool   `json:"debug_invariants,omitempty"`
+	InvariantMode       string `json:"invariant_mode,omitempty"`
+	InvariantIntervalMs int    `json:"invariant_interval_ms,omitempty"`
+
+	// Order arrival process of the harness; nil places orders at a constant rate.
+	Arrival *ArrivalConfig `json:"arrival,omitempty"`
+
+	// Per-temperature slot limits on every shelf, keyed by temperature.
+	ShelfQuotas map[string]QuotaConfig `json:"shelf_quotas,omitempty"`
+
+	// Where orders of each temperature may be stored, keyed by temperature, in order of
+	// preference. Temperatures without a route use the built-in ones: hot orders go to
+	// heaters then shelves, cold orders to coolers then shelves, and others to shelves.
+	Compatibility map[string][]RouteConfig `json:"compatibility,omitempty"`
+
+	// Group where room is made by discarding once every group of an order's route is
+	// full: cooler, heater or shelf (default). Routes without it fall back to their last
+	// group.
+	DiscardGroup string `json:"discard_group,omitempty"`
+
+	// Simulated temperature sensors of every unit of a group, keyed by group name (cooler,
+	// heater or shelf). A unit's own sensor takes precedence.
+	Sensors map[string]*SensorConfig `json:"sensors,omitempty"`
+}
+
+// UnitConfig describes a single storage unit.
+type UnitConfig struct {
+	Name            string  `json:"name,omitempty"`             // Defaults to the group's kind and position, e.g. Cooler-2.
+	Capacity        int     `json:"capacity"`                   // Number of slots.
+	DecayMultiplier float64 `json:"decay_multiplier,omitempty"` // Freshness decay rate relative to normal; 1 if zero.
+	Enabled         *bool   `json:"enabled,omitempty"`          // Whether the unit takes orders; true if unset.
+
+	Sensor *SensorConfig `json:"sensor,omitempty"` // Simulated temperature sensor; the group's if nil.
+}
+
+// IsEnabled reports whether the unit takes orders.
+func (u UnitConfig) IsEnabled() bool {
+	return u.Enabled == nil || *u.Enabled
+}
+
+// ArrivalConfig selects and parameterizes an order arrival model.
+type ArrivalConfig struct {
+	Model        string      `json:"model"`                    // constant, poisson, curve, burst or trace.
+	Rate         float64     `json:"rate,omitempty"`           // Orders per second (poisson, and burst between bursts).
+	BurstRate    float64     `json:"burst_rate,omitempty"`     // Orders per second during a burst (burst).
+	BurstEveryS  float64     `json:"burst_every_s,omitempty"`  // Seconds between the starts of consecutive bursts (burst).
+	BurstLengthS float64     `json:"burst_length_s,omitempty"` // Seconds each burst lasts (burst).
+	Curve        []RatePoint `json:"curve,omitempty"`          // Orders per second through the day (curve).
+	StartHour    float64     `json:"start_hour,omitempty"`     // Hour of the day at which the run starts (curve).
+	Trace        string      `json:"trace,omitempty"`          // File of recorded arrival timestamps (trace).
+}
+
+// QuotaConfig limits the slots orders of one temperature take on a shelf.
+type QuotaConfig struct {
+	Max      int `json:"max,omitempty"`      // Most slots these orders may take; no limit if zero.
+	Reserved int `json:"reserved,omitempty"` // Slots kept free for these orders while they use fewer.
+}
+
+// RouteConfig is a storage group orders of a temperature may be stored in.
+type RouteConfig struct {
+	Group           string  `json:"group"`                      // cooler, heater or shelf.
+	DecayMultiplier float64 `json:"decay_multiplier,omitempty"` // Decay rate of these orders in the group; 1 if zero.
+}
+
+// SensorConfig describes a simulated temperature sensor and how the temperature it reads
+// affects the decay of the orders in its unit.
+type SensorConfig struct {
+	SetpointC      float64      `json:"setpoint_c"`                  // Temperature the unit starts at.
+	DriftCPerMin   float64      `json:"drift_c_per_min,omitempty"`   // Steady change, e.g. a failing cooler warming up.
+	DoorOpenDeltaC float64      `json:"door_open_delta_c,omitempty"` // Jump in temperature when the door opens.
+	DoorOpenEveryS float64      `json:"door_open_every_s,omitempty"` // Period of door openings; none if zero.
+	RecoveryS      float64      `json:"recovery_s,omitempty"`        // Time to recover linearly from a door opening.
+	Decay          []DecayPoint `json:"decay,omitempty"`             // Decay multiplier by temperature; 1 if empty.
+}
+
+// DecayPoint is the decay multiplier at a temperature. Multipliers between points are
+// interpolated linearly and held constant beyond the first and last points.
+type DecayPoint struct {
+	TempC      float64 `json:"temp_c"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// RatePoint is the arrival rate at an hour of the day. Rates between points are
+// interpolated linearly, wrapping around midnight.
+type RatePoint struct {
+	Hour float64 `json:"hour"`
+	Rate float64 `json:"rate"`
 }
 
 // DefaultConfig returns the default configuration.
@@ -66,6 +180,19 @@ func LoadConfig(configPath string) FulfillmentConfig {
 	return config
 }
 
+// ReadConfig reads configuration from an existing JSON file, reporting any error.
+func ReadConfig(configPath string) (FulfillmentConfig, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return FulfillmentConfig{}, err
+	}
+	var config FulfillmentConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return FulfillmentConfig{}, fmt.Errorf("failed to parse %v: %v", configPath, err)
+	}
+	return config, nil
+}
+
 // saveConfig saves the configuration to a JSON file
 func saveConfig(configPath string, config FulfillmentConfig) {
 	data, err := json.MarshalIndent(config, "", "  ")
@@ -6,6 +6,10 @@ const (
 	ACTION_TYPE_MOVE    = "move"
 	ACTION_TYPE_PICKUP  = "pickup"
 	ACTION_TYPE_DISCARD = "discard"
+
+	// Operator annotations, kept in the action log but never submitted.
+	ACTION_TYPE_PIN   = "pin"
+	ACTION_TYPE_UNPIN = "unpin"
 )
 
 // Temperature type constants
@@ -0,0 +1,58 @@
+package entity
+
+// Quota limits the slots orders of one temperature take in a storage.
+type Quota struct {
+	Max      int // Most slots these orders may take; no limit if zero.
+	Reserved int // Slots kept free for these orders while they use fewer.
+}
+
+// UsedBy returns the number of slots taken by stored orders of a temperature.
+func (s *Storage) UsedBy(temp string) int {
+	return s.usedBy[temp]
+}
+
+// heldFor returns the free slots reserved for temperatures other than temp.
+func (s *Storage) heldFor(temp string) int {
+	held := 0
+	for t, q := range s.Quotas {
+		if t != temp && q.Reserved > s.usedBy[t] {
+			held += q.Reserved - s.usedBy[t]
+		}
+	}
+	return held
+}
+
+// underMax reports whether the order fits within the maximum of its temperature once
+// freed slots of that temperature are given back.
+func (s *Storage) underMax(order Order, freed int) bool {
+	q := s.Quotas[order.Temperature]
+	return q.Max <= 0 || s.usedBy[order.Temperature]-freed+order.Units() <= q.Max
+}
+
+// CanHold reports whether the storage could hold an order once emptied.
+func (s *Storage) CanHold(order Order) bool {
+	if !s.Available() || !s.underMax(order, s.usedBy[order.Temperature]) {
+		return false
+	}
+	reserved := 0
+	for t, q := range s.Quotas {
+		if t != order.Temperature {
+			reserved += q.Reserved
+		}
+	}
+	return order.Units() <= s.Capacity-reserved
+}
+
+// Helps reports whether removing a stored order brings the storage closer to fitting
+// another order: it must free a slot that order may use. Removing an order of another
+// temperature within its reserved slots only returns them to the reservation, and while
+// the order's temperature is over its maximum only removing one of its own orders helps.
+func (s *Storage) Helps(stored, order Order) bool {
+	if stored.Temperature == order.Temperature {
+		return true
+	}
+	if !s.underMax(order, 0) {
+		return false
+	}
+	return s.usedBy[stored.Temperature] > s.Quotas[stored.Temperature].Reserved
+}
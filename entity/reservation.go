@@ -0,0 +1,133 @@
+package entity
+
+import "time"
+
+// Reservation holds back slots of a storage group for upcoming orders: either for one
+// order, or for any order of a temperature being placed during a time window. Orders
+// moving into the group never take slots reserved for others.
+type Reservation struct {
+	ID          string
+	Slots       int       // Slots still held; placing covered orders uses them up.
+	OrderID     string    // Order the slots are held for; if blank, orders of Temperature being placed.
+	Temperature string    // Temperature of the orders that may use the slots without an OrderID; any if blank.
+	From        time.Time // Start of the window; the slots are held at once if zero.
+	Until       time.Time // End of the window, when the reservation expires; never if zero.
+	Active      bool      // Whether the window had started at the latest Expire.
+}
+
+// covers reports whether an order being placed or, unless placing, moved may use the slots.
+func (r *Reservation) covers(order Order, placing bool) bool {
+	if r.OrderID != "" {
+		return r.OrderID == order.ID
+	}
+	return placing && (r.Temperature == "" || r.Temperature == order.Temperature)
+}
+
+// Reserve adds a reservation to the group. It holds slots from the next Expire on, or at
+// once if its window has no start.
+func (sg *StorageGroup) Reserve(r Reservation) {
+	sg.storeLock.Lock()
+	defer sg.storeLock.Unlock()
+	r.Active = r.From.IsZero()
+	sg.reservations = append(sg.reservations, &r)
+}
+
+// Unreserve removes the reservation with the given ID, reporting whether there was one.
+func (sg *StorageGroup) Unreserve(id string) bool {
+	sg.storeLock.Lock()
+	defer sg.storeLock.Unlock()
+	for i, r := range sg.reservations {
+		if r.ID == id {
+			sg.reservations = append(sg.reservations[:i], sg.reservations[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// use takes the slots of an order placed or, unless placing, moved into the group out of
+// the active reservations covering it, dropping those with no slots left.
+func (sg *StorageGroup) use(order Order, placing bool) {
+	units := order.Units()
+	kept := sg.reservations[:0]
+	for _, r := range sg.reservations {
+		if units > 0 && r.Active && r.covers(order, placing) {
+			n := min(units, r.Slots)
+			r.Slots -= n
+			units -= n
+		}
+		if r.Slots > 0 {
+			kept = append(kept, r)
+		}
+	}
+	sg.reservations = kept
+}
+
+// Release drops the reservations held for an order, such as one that left the system.
+func (sg *StorageGroup) Release(orderID string) {
+	sg.storeLock.Lock()
+	defer sg.storeLock.Unlock()
+	sg.release(orderID)
+}
+
+func (sg *StorageGroup) release(orderID string) {
+	kept := sg.reservations[:0]
+	for _, r := range sg.reservations {
+		if r.OrderID != orderID {
+			kept = append(kept, r)
+		}
+	}
+	sg.reservations = kept
+}
+
+// Expire drops the reservations whose window has ended at now, returning them, and
+// activates those whose window has started.
+func (sg *StorageGroup) Expire(now time.Time) []Reservation {
+	sg.storeLock.Lock()
+	defer sg.storeLock.Unlock()
+	var expired []Reservation
+	kept := sg.reservations[:0]
+	for _, r := range sg.reservations {
+		if !r.Until.IsZero() && !now.Before(r.Until) {
+			expired = append(expired, *r)
+			continue
+		}
+		r.Active = !now.Before(r.From)
+		kept = append(kept, r)
+	}
+	sg.reservations = kept
+	return expired
+}
+
+// Reservations returns copies of the group's reservations.
+func (sg *StorageGroup) Reservations() []Reservation {
+	sg.storeLock.RLock()
+	defer sg.storeLock.RUnlock()
+	reservations := make([]Reservation, len(sg.reservations))
+	for i, r := range sg.reservations {
+		reservations[i] = *r
+	}
+	return reservations
+}
+
+// held returns the active reserved slots an order may not use.
+func (sg *StorageGroup) held(order Order, placing bool) int {
+	held := 0
+	for _, r := range sg.reservations {
+		if r.Active && !r.covers(order, placing) {
+			held += r.Slots
+		}
+	}
+	return held
+}
+
+// free returns the free slots of the group's available storages.
+func (sg *StorageGroup) free() int {
+	free := 0
+	for _, s := range sg.Storages {
+		if s.Available() && s.Capacity > s.used {
+			free += s.Capacity - s.used
+		}
+	}
+	return free
+}
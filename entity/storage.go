@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"challenge/config"
 	"log"
 	"sync"
 	"time"
@@ -13,20 +14,73 @@ type Order struct {
 	Temperature      string        // Temperature requirement
 	Freshness        time.Duration // Freshness duration in ideal conditions.
 	InitialFreshness time.Duration // Initial freshness duration in ideal conditions.
+	Size             int           // Storage slots the order takes up; zero counts as one.
+	Value            float64       // Menu price of the order.
+}
+
+// ShelfLife returns the remaining freshness an order starts with when placed,
+// matching the accounting used by StoredOrder.RemainingFreshness.
+func (o Order) ShelfLife() time.Duration {
+	freshness := o.InitialFreshness
+	if freshness == 0 {
+		freshness = o.Freshness
+	}
+	if o.Temperature == config.TEMP_TYPE_ROOM {
+		return freshness
+	}
+	return freshness / 2
+}
+
+// Units returns the number of storage slots the order takes up.
+func (o Order) Units() int {
+	if o.Size > 0 {
+		return o.Size
+	}
+	return 1
 }
 
 // StoredOrder wraps an Order along with its placement time.
 type StoredOrder struct {
 	Order    Order
 	PlacedAt time.Time
+	Storage  string    // Name of the storage unit currently holding the order.
+	Decay    float64   // Freshness decay rate multiplier of that storage; zero counts as one.
+	Pinned   bool      // Pinned by an operator: never moved or discarded automatically.
+	Moves    int       // Number of times the order was moved.
+	MovedAt  time.Time // When the order was last moved; zero if never.
+}
+
+// Rebase restarts the order's freshness accounting at now, keeping its remaining
+// freshness, so it can continue decaying at a different rate.
+func (so *StoredOrder) Rebase(now time.Time) {
+	remaining := so.RemainingFreshnessAt(now)
+	if so.Order.Temperature != config.TEMP_TYPE_ROOM {
+		remaining *= 2
+	}
+	so.Order.Freshness = remaining
+	so.PlacedAt = now
 }
 
+// Storage statuses.
+const (
+	StatusOnline   = "online"   // Takes orders.
+	StatusDegraded = "degraded" // Takes no new orders; its orders leave as room appears elsewhere.
+	StatusOffline  = "offline"  // Holds no orders; they are moved out or discarded.
+)
+
 // Storage represents a single storage unit with a fixed capacity.
 type Storage struct {
-	Name     string                  // Storage unit name.
-	Capacity int                     // Maximum orders it can hold.
-	Orders   map[string]*StoredOrder // Map of order IDs to stored orders.
-	Lock     sync.RWMutex            // Protects access to Orders.
+	Name       string                  // Storage unit name.
+	Capacity   int                     // Number of slots it has.
+	Orders     map[string]*StoredOrder // Map of order IDs to stored orders.
+	Lock       sync.RWMutex            // Protects access to Orders.
+	Decay      float64                 // Freshness decay rate multiplier; zero counts as one.
+	Conditions float64                 // Decay multiplier from the current temperature; zero counts as one.
+	TempDecay  map[string]float64      // Decay multiplier by order temperature; missing counts as one.
+	Status     string                  // Online, degraded or offline; blank counts as online.
+	Quotas     map[string]Quota        // Per-temperature slot limits; none if empty.
+	used       int                     // Slots taken by the stored orders.
+	usedBy     map[string]int          // Slots taken by the stored orders, by temperature.
 }
 
 // NewStorage creates a new storage instance.
@@ -48,13 +102,18 @@ func (s *Storage) GetOrder(orderID string) (*StoredOrder, bool) {
 func (s *Storage) Add(order *StoredOrder) bool {
 	log.Println("Adding order to storage, order:", order.Order.ID)
 	// If the order is already present, update it.
-	if _, exists := s.Orders[order.Order.ID]; exists {
+	if old, exists := s.Orders[order.Order.ID]; exists {
+		order.Storage, order.Decay = s.Name, s.DecayRateFor(order.Order.Temperature)
 		s.Orders[order.Order.ID] = order
+		s.account(old.Order, -1)
+		s.account(order.Order, 1)
 		return true
 	}
 	// Otherwise, if there is room, add it.
-	if len(s.Orders) < s.Capacity {
+	if s.Fits(order.Order) {
+		order.Storage, order.Decay = s.Name, s.DecayRateFor(order.Order.Temperature)
 		s.Orders[order.Order.ID] = order
+		s.account(order.Order, 1)
 		return true
 	}
 	return false
@@ -65,22 +124,77 @@ func (s *Storage) Remove(orderID string) (*StoredOrder, bool) {
 	so, exists := s.Orders[orderID]
 	if exists {
 		delete(s.Orders, orderID)
+		s.account(so.Order, -1)
 	}
 	return so, exists
 }
 
-// Action represents an event (place, move, pickup, discard) on an order.
-type Action struct {
-	Timestamp int64  // Unix timestamp in microseconds.
-	OrderID   string // Order identifier.
-	Action    string // Action type.
+// account adds (sign 1) or removes (sign -1) the slots of an order to the usage counts.
+func (s *Storage) account(order Order, sign int) {
+	s.used += sign * order.Units()
+	if s.usedBy == nil {
+		s.usedBy = make(map[string]int)
+	}
+	s.usedBy[order.Temperature] += sign * order.Units()
+}
+
+// Used returns the number of slots taken by the stored orders.
+func (s *Storage) Used() int {
+	return s.used
+}
+
+// DecayRate returns how fast orders in the storage use up freshness relative to normal:
+// the storage's own multiplier times that of the current conditions.
+func (s *Storage) DecayRate() float64 {
+	rate := 1.0
+	if s.Decay > 0 {
+		rate *= s.Decay
+	}
+	if s.Conditions > 0 {
+		rate *= s.Conditions
+	}
+	return rate
+}
+
+// DecayRateFor returns how fast orders of a temperature use up freshness in the storage
+// relative to normal.
+func (s *Storage) DecayRateFor(temp string) float64 {
+	rate := s.DecayRate()
+	if m, ok := s.TempDecay[temp]; ok && m > 0 {
+		rate *= m
+	}
+	return rate
+}
+
+// SetConditions changes the conditions multiplier at now. Stored orders keep the
+// freshness they have left and decay at the new rate from now on.
+func (s *Storage) SetConditions(multiplier float64, now time.Time) {
+	if multiplier == s.Conditions {
+		return
+	}
+	s.Conditions = multiplier
+	for _, so := range s.Orders {
+		so.Rebase(now)
+		so.Decay = s.DecayRateFor(so.Order.Temperature)
+	}
+}
+
+// Available reports whether the storage is online and so takes orders.
+func (s *Storage) Available() bool {
+	return s.Status == "" || s.Status == StatusOnline
+}
+
+// Fits reports whether the storage takes orders and has enough free slots for this one,
+// within its quotas.
+func (s *Storage) Fits(order Order) bool {
+	return s.Available() && s.used+order.Units() <= s.Capacity-s.heldFor(order.Temperature) && s.underMax(order, 0)
 }
 
 // IsFull checks if the storage is at capacity.
 func (s *Storage) IsFull() bool {
 	s.Lock.RLock() // Use a read lock for read-only access.
 	defer s.Lock.RUnlock()
-	return len(s.Orders) >= s.Capacity
+	return !s.Available() || s.used >= s.Capacity
 }
 
 // ListOrders returns a snapshot of orders in storage.
@@ -7,9 +7,21 @@ import (
 	"time"
 )
 
+// Fit strategies choose the storage of a group that receives an order.
+const (
+	FitFirst       = "first-fit"    // The first storage with room.
+	FitBest        = "best-fit"     // The storage left with the fewest free slots.
+	FitLeastLoaded = "least-loaded" // The storage using the smallest fraction of its capacity.
+)
+
+// FitStrategies lists the fit strategies.
+var FitStrategies = []string{FitFirst, FitBest, FitLeastLoaded}
+
 type StorageGroup struct {
-	Storages  []*Storage
-	storeLock sync.RWMutex // Use RWMutex for the storages
+	Storages     []*Storage
+	Fit          string         // Fit strategy; first-fit if blank.
+	reservations []*Reservation // Slots held back for upcoming orders.
+	storeLock    sync.RWMutex   // Use RWMutex for the storages
 }
 
 // Call this whenever adding an order
@@ -18,20 +30,68 @@ func (sg *StorageGroup) Add(order *StoredOrder) bool {
 	sg.storeLock.Lock()
 	defer sg.storeLock.Unlock()
 	log.Println("Adding order to storage group, order:", order.Order.ID)
-	for _, storage := range sg.Storages {
-		log.Println("Checking storage:", storage.Name)
-		if !storage.IsFull() {
-			log.Println("Storage is not full, adding order to storage")
-			if storage.Add(order) {
-				// Successfully added to storage, now add to the priority queue
-				return true
-			}
-		}
+	if storage := sg.pick(order.Order, true); storage != nil {
+		log.Println("Storage has room, adding order to storage:", storage.Name)
+		return sg.store(storage, order, true)
 	}
 	// If all storages are full, return false
 	return false
 }
 
+// Store adds an order to one of the group's storages, chosen by the caller, and settles
+// the group's reservations: the order uses up the slots of the active reservations
+// covering it, as an order being placed if placing or else as one being moved, and the
+// reservations held for it end.
+func (sg *StorageGroup) Store(storage *Storage, order *StoredOrder, placing bool) bool {
+	sg.storeLock.Lock()
+	defer sg.storeLock.Unlock()
+	return sg.store(storage, order, placing)
+}
+
+func (sg *StorageGroup) store(storage *Storage, order *StoredOrder, placing bool) bool {
+	if !storage.Add(order) {
+		return false
+	}
+	sg.use(order.Order, placing)
+	sg.release(order.Order.ID)
+	return true
+}
+
+// Pick returns the storage the fit strategy chooses for an order moving into the group, or
+// nil if none has room outside the slots reserved for other orders.
+func (sg *StorageGroup) Pick(order Order) *Storage {
+	sg.storeLock.RLock()
+	defer sg.storeLock.RUnlock()
+	return sg.pick(order, false)
+}
+
+// pick chooses the storage for an order being placed or, unless placing, moved.
+func (sg *StorageGroup) pick(order Order, placing bool) *Storage {
+	if held := sg.held(order, placing); held > 0 && sg.free()-order.Units() < held {
+		return nil
+	}
+	var best *Storage
+	var bestKey float64
+	for _, storage := range sg.Storages {
+		if !storage.Fits(order) {
+			continue
+		}
+		var key float64
+		switch sg.Fit {
+		case FitBest:
+			key = float64(storage.Capacity - storage.used - order.Units())
+		case FitLeastLoaded:
+			key = float64(storage.used) / float64(storage.Capacity)
+		default:
+			return storage
+		}
+		if best == nil || key < bestKey {
+			best, bestKey = storage, key
+		}
+	}
+	return best
+}
+
 // Call this whenever removing an order
 func (sg *StorageGroup) Remove(orderID string) (*StoredOrder, bool) {
 	sg.storeLock.Lock()
@@ -67,7 +127,15 @@ func (sg *StorageGroup) GetLeastFreshOrder() (*StoredOrder, bool) {
 
 // Helper method to calculate remaining freshness
 func (so *StoredOrder) RemainingFreshness() time.Duration {
-	elapsed := time.Since(so.PlacedAt)
+	return so.RemainingFreshnessAt(time.Now())
+}
+
+// RemainingFreshnessAt calculates the remaining freshness at the given time.
+func (so *StoredOrder) RemainingFreshnessAt(now time.Time) time.Duration {
+	elapsed := now.Sub(so.PlacedAt)
+	if so.Decay > 0 {
+		elapsed = time.Duration(float64(elapsed) * so.Decay)
+	}
 	if so.Order.Temperature == config.TEMP_TYPE_ROOM {
 		return so.Order.Freshness - elapsed
 	}
@@ -84,6 +152,25 @@ func (sg *StorageGroup) ListOrders() []*StoredOrder {
 	return orders
 }
 
+// Fits reports whether some storage of the group has room for an order being placed.
+func (sg *StorageGroup) Fits(order Order) bool {
+	sg.storeLock.RLock()
+	defer sg.storeLock.RUnlock()
+	return sg.pick(order, true) != nil
+}
+
+// CanHold reports whether some storage of the group is large enough for an order once emptied.
+func (sg *StorageGroup) CanHold(order Order) bool {
+	sg.storeLock.RLock()
+	defer sg.storeLock.RUnlock()
+	for _, storage := range sg.Storages {
+		if storage.CanHold(order) {
+			return true
+		}
+	}
+	return false
+}
+
 func (sg *StorageGroup) IsFull() bool {
 	sg.storeLock.RLock()
 	defer sg.storeLock.RUnlock()
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"challenge/catalog"
+	"challenge/workload"
+)
+
+// runGen implements the gen command: it draws a synthetic order workload from a food
+// catalog and writes it in the challenge server's order format.
+func runGen(args []string) {
+	cmd := flag.NewFlagSet("gen", flag.ExitOnError)
+	count := cmd.Int("count", 100, "Number of orders to generate")
+	seed := cmd.Int64("seed", 1, "Seed for the generated orders")
+	catalogPath := cmd.String("catalog", "", "JSON food catalog (built-in menu if blank)")
+	mix := cmd.String("mix", "", "Temperature mix, e.g. hot=0.4,cold=0.3,room=0.3 (item weights alone if blank)")
+	out := cmd.String("out", "", "Output file (standard output if blank)")
+	format := cmd.String("format", "", "Output format: json or jsonl (inferred from --out extension if blank, json otherwise)")
+	timestamps := cmd.Bool("timestamps", false, "Include arrival and pickup times in Unix microseconds")
+	start := cmd.String("start", "2024-01-01T00:00:00Z", "Arrival time of the first order (RFC 3339), with --timestamps")
+	orderRate := cmd.Duration("rate", 500*time.Millisecond, "Inverse order rate, with --timestamps")
+	arrivalSpec := cmd.String("arrival", "", "Arrival model, e.g. poisson:rate=2 (constant --rate if blank), with --timestamps")
+	minPickup := cmd.Duration("min", 4*time.Second, "Minimum pickup time, with --timestamps")
+	maxPickup := cmd.Duration("max", 8*time.Second, "Maximum pickup time, with --timestamps")
+	cmd.Parse(args)
+
+	spec := workload.GenSpec{
+		Count:      *count,
+		Seed:       *seed,
+		Catalog:    catalog.Default(),
+		Timestamps: *timestamps,
+		Rate:       *orderRate,
+		MinPickup:  *minPickup,
+		MaxPickup:  *maxPickup,
+	}
+	var err error
+	if *catalogPath != "" {
+		if spec.Catalog, err = catalog.Load(*catalogPath); err != nil {
+			log.Fatalf("gen: %v", err)
+		}
+	}
+	if *mix != "" {
+		if spec.Mix, err = workload.ParseMix(*mix); err != nil {
+			log.Fatalf("gen: %v", err)
+		}
+	}
+	if spec.Start, err = time.Parse(time.RFC3339, *start); err != nil {
+		log.Fatalf("gen: invalid --start: %v", err)
+	}
+	if spec.Arrivals, err = arrivalModel(*arrivalSpec, nil, *orderRate); err != nil {
+		log.Fatalf("gen: %v", err)
+	}
+
+	orders, err := workload.Generate(spec)
+	if err != nil {
+		log.Fatalf("gen: %v", err)
+	}
+
+	if *format == "" && filepath.Ext(*out) == ".jsonl" {
+		*format = "jsonl"
+	}
+	write := workload.WriteJSON
+	switch *format {
+	case "", "json":
+	case "jsonl":
+		write = workload.WriteJSONL
+	default:
+		log.Fatalf("gen: unknown format %q", *format)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("gen: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w, orders); err != nil {
+		log.Fatalf("gen: %v", err)
+	}
+	if *out != "" {
+		log.Printf("Wrote %d orders to %s", len(orders), *out)
+	}
+}
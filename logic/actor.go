@@ -0,0 +1,66 @@
+package logic
+
+import "log"
+
+// All state mutations of a FulfillmentSystem run as commands on a single goroutine, the
+// event loop started by NewFulfillmentSystem. Public methods submit a command and wait for
+// its reply, so every sequence of place, pickup and move operations is linearizable and
+// the action log is totally ordered. Before each command, the loop samples the unit
+// sensors, so decay rates follow the current temperatures, and expires reservations.
+// Code running inside a command must call the unexported variants directly; submitting
+// a command from the loop would deadlock.
+
+// command is a unit of work for the event loop.
+type command struct {
+	run   func()
+	reply chan struct{}
+}
+
+// loop processes commands one at a time until the system is closed.
+func (fs *FulfillmentSystem) loop() {
+	for {
+		select {
+		case cmd := <-fs.commands:
+			fs.sampleSensors()
+			fs.expireReservations()
+			cmd.run()
+			fs.afterCommand()
+			close(cmd.reply)
+		case <-fs.closed:
+			return
+		}
+	}
+}
+
+// do runs f on the event loop and waits for it to finish. It returns false without running
+// f if the system has been closed.
+func (fs *FulfillmentSystem) do(f func()) bool {
+	cmd := command{run: f, reply: make(chan struct{})}
+	select {
+	case fs.commands <- cmd:
+		<-cmd.reply
+		return true
+	case <-fs.closed:
+		log.Printf("Fulfillment system is closed, dropping command")
+		return false
+	}
+}
+
+// Close stops the event loop. Operations submitted afterwards are dropped. It is safe to
+// call Close more than once.
+func (fs *FulfillmentSystem) Close() {
+	fs.closeOnce.Do(func() {
+		close(fs.closed)
+	})
+}
+
+// ActionLog returns a copy of the action log, consistent with every completed operation.
+func (fs *FulfillmentSystem) ActionLog() []Action {
+	var actions []Action
+	if !fs.do(func() {
+		actions = append([]Action(nil), fs.Actions...)
+	}) {
+		return append([]Action(nil), fs.Actions...)
+	}
+	return actions
+}
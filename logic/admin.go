@@ -0,0 +1,237 @@
+package logic
+
+import (
+	"bufio"
+	"challenge/entity"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminUsage lists the commands understood by ExecAdmin.
+const adminUsage = "commands: status | online <unit> | degraded <unit> | offline <unit> | door <unit> | drift <unit> <degrees/min> | reserve <group> <slots> [order=<id>] [temp=<t>] [in=<d>] [for=<d>] | unreserve <id> | pin <order> | unpin <order> | move <order> <unit> [reason] | discard <order> <reason> | trace <order>"
+
+// ExecAdmin runs a single admin command and returns its output:
+//
+//	status           list every unit with its status and used slots
+//	online <unit>    bring a unit back online
+//	degraded <unit>  stop placing orders in a unit and move its orders out as room appears
+//	offline <unit>   take a unit out of service, evacuating its orders
+//	door <unit>      open the door of a unit with a sensor
+//	drift <unit> <d> make the temperature of a unit with a sensor drift d degrees per minute
+//	reserve <group> <slots> [order=<id>] [temp=<t>] [in=<d>] [for=<d>]
+//	                 hold slots of a group for an order or for orders of a temperature,
+//	                 starting in d and lasting d (at once and until cancelled by default)
+//	unreserve <id>   cancel a reservation
+//	pin <order>      keep an order where it is until picked up or moved by an operator
+//	unpin <order>    hand a pinned order back to the automatic placement
+//	move <order> <unit> [reason]
+//	                 move an order to a unit
+//	discard <order> <reason>
+//	                 discard an order
+//	trace <order>    list the actions on an order with their reasons and candidates
+func (fs *FulfillmentSystem) ExecAdmin(line string) (string, error) {
+	return fs.ExecAdminAs(DefaultOperator, line)
+}
+
+// ExecAdminAs runs a single admin command like ExecAdmin, recording operator as the one
+// who ordered any override.
+func (fs *FulfillmentSystem) ExecAdminAs(operator, line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	switch cmd := strings.ToLower(fields[0]); cmd {
+	case "status":
+		var b strings.Builder
+		snap := fs.Snapshot()
+		for _, s := range snap.Storages {
+			fmt.Fprintf(&b, "%-12s %-7s %-8s %d/%d decay %.2fx", s.Name, s.Group, s.Status, s.Used, s.Capacity, s.Decay)
+			if s.TempC != nil {
+				fmt.Fprintf(&b, " %.1fC", *s.TempC)
+			}
+			b.WriteString("\n")
+		}
+		for _, r := range snap.Reservations {
+			fmt.Fprintf(&b, "%-12s %-7s %d slots", r.ID, r.Group, r.Slots)
+			if r.OrderID != "" {
+				fmt.Fprintf(&b, " for order %s", r.OrderID)
+			} else if r.Temperature != "" {
+				fmt.Fprintf(&b, " for %s orders", r.Temperature)
+			}
+			if r.Until != nil {
+				fmt.Fprintf(&b, " until %s", r.Until.Format(time.RFC3339))
+			}
+			if !r.Active {
+				b.WriteString(" (pending)")
+			}
+			b.WriteString("\n")
+		}
+		return b.String(), nil
+	case "online", "degraded", "offline":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: %s <unit>", cmd)
+		}
+		if err := fs.SetUnitStatus(fields[1], cmd); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s is %s\n", fields[1], cmd), nil
+	case "door":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: door <unit>")
+		}
+		if err := fs.OpenDoor(fields[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s door opened\n", fields[1]), nil
+	case "drift":
+		if len(fields) != 3 {
+			return "", fmt.Errorf("usage: drift <unit> <degrees/min>")
+		}
+		drift, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid drift %q", fields[2])
+		}
+		if err := fs.SetDrift(fields[1], drift); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s drifts %g degrees per minute\n", fields[1], drift), nil
+	case "reserve":
+		return fs.adminReserve(fields[1:])
+	case "unreserve":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: unreserve <id>")
+		}
+		if err := fs.CancelReservation(fields[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s cancelled\n", fields[1]), nil
+	case "pin", "unpin":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: %s <order>", cmd)
+		}
+		set := fs.PinOrder
+		if cmd == "unpin" {
+			set = fs.UnpinOrder
+		}
+		if err := set(fields[1], operator); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("order %s %sned\n", fields[1], cmd), nil
+	case "move":
+		if len(fields) < 3 {
+			return "", fmt.Errorf("usage: move <order> <unit> [reason]")
+		}
+		if err := fs.MoveOrder(fields[1], fields[2], operator, strings.Join(fields[3:], " ")); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("order %s moved to %s\n", fields[1], fields[2]), nil
+	case "discard":
+		if len(fields) < 3 {
+			return "", fmt.Errorf("usage: discard <order> <reason>")
+		}
+		if err := fs.DiscardOrder(fields[1], operator, strings.Join(fields[2:], " ")); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("order %s discarded\n", fields[1]), nil
+	case "trace":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: trace <order>")
+		}
+		trace := fs.Trace(fields[1])
+		if len(trace) == 0 {
+			return "", fmt.Errorf("no actions on order %s", fields[1])
+		}
+		var b strings.Builder
+		for _, a := range trace {
+			fmt.Fprintf(&b, "%s %-7s %s -> %s %v [%s]", time.UnixMicro(a.Timestamp).Format(time.RFC3339Nano), a.Action, orDash(a.Source), orDash(a.Target), a.Freshness.Round(time.Millisecond), a.Rule)
+			if a.Operator != "" {
+				fmt.Fprintf(&b, " by %s", a.Operator)
+			}
+			if a.Reason != "" {
+				fmt.Fprintf(&b, ": %s", a.Reason)
+			}
+			if d := a.Decision; d != nil {
+				for _, c := range d.Candidates {
+					fmt.Fprintf(&b, "\n    candidate %-8s %-12s %v", c.OrderID, c.Storage, c.Freshness.Round(time.Millisecond))
+				}
+			}
+			b.WriteString("\n")
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("unknown command %q (%s)", fields[0], adminUsage)
+}
+
+// AdminConsole runs admin commands read one per line from r on behalf of operator,
+// writing their output and errors to w, until r is exhausted.
+func (fs *FulfillmentSystem) AdminConsole(r io.Reader, w io.Writer, operator string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		out, err := fs.ExecAdminAs(operator, scanner.Text())
+		if err != nil {
+			fmt.Fprintf(w, "error: %v\n", err)
+			continue
+		}
+		fmt.Fprint(w, out)
+	}
+}
+
+// adminReserve parses and makes a reservation from the arguments of a reserve command.
+func (fs *FulfillmentSystem) adminReserve(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("usage: reserve <group> <slots> [order=<id>] [temp=<t>] [in=<d>] [for=<d>]")
+	}
+	slots, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid slots %q", args[1])
+	}
+	r := entity.Reservation{Slots: slots}
+	var in, length time.Duration
+	for _, arg := range args[2:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid reserve option %q, want key=value", arg)
+		}
+		switch key {
+		case "order":
+			r.OrderID = value
+		case "temp":
+			r.Temperature = value
+		case "in", "for":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid %s duration %q", key, value)
+			}
+			if key == "in" {
+				in = d
+			} else {
+				length = d
+			}
+		default:
+			return "", fmt.Errorf("unknown reserve option %q", key)
+		}
+	}
+	now := fs.clock.Now()
+	if in > 0 {
+		r.From = now.Add(in)
+	}
+	if length > 0 {
+		r.Until = now.Add(in + length)
+	}
+	id, err := fs.Reserve(args[0], r)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s reserves %d %s slots\n", id, slots, args[0]), nil
+}
+
+// orDash returns s, or "-" if it is blank.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"fmt"
+	"time"
+)
+
+// sampleSensors reads every unit's sensor and applies the decay multiplier of its current
+// temperature. The event loop samples before every command, so decay follows the
+// temperature in steps, changing at most once per operation.
+func (fs *FulfillmentSystem) sampleSensors() {
+	if len(fs.sensors) == 0 {
+		return
+	}
+	now := fs.clock.Now()
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			if sensor, ok := fs.sensors[s.Name]; ok {
+				s.SetConditions(sensor.MultiplierAt(now), now)
+			}
+		}
+	}
+}
+
+// temperatureAt returns the temperature read by a unit's sensor, if it has one.
+func (fs *FulfillmentSystem) temperatureAt(unit string, now time.Time) (float64, bool) {
+	sensor, ok := fs.sensors[unit]
+	if !ok {
+		return 0, false
+	}
+	return sensor.TemperatureAt(now), true
+}
+
+// OpenDoor simulates opening the door of a unit with a sensor, raising its temperature
+// until it recovers.
+func (fs *FulfillmentSystem) OpenDoor(unit string) error {
+	return fs.withSensor(unit, func(now time.Time) {
+		fs.sensors[unit].OpenDoor(now)
+	})
+}
+
+// SetDrift changes how fast the temperature of a unit with a sensor drifts, in degrees
+// per minute; a positive drift on a cooler simulates it failing.
+func (fs *FulfillmentSystem) SetDrift(unit string, degreesPerMinute float64) error {
+	return fs.withSensor(unit, func(now time.Time) {
+		fs.sensors[unit].SetDrift(now, degreesPerMinute)
+	})
+}
+
+// withSensor runs f on the event loop if the unit has a sensor, then applies the
+// resulting conditions.
+func (fs *FulfillmentSystem) withSensor(unit string, f func(now time.Time)) error {
+	var err error
+	if !fs.do(func() {
+		if _, ok := fs.sensors[unit]; !ok {
+			err = fmt.Errorf("storage unit %q has no sensor", unit)
+			return
+		}
+		f(fs.clock.Now())
+		fs.sampleSensors()
+	}) {
+		return fmt.Errorf("fulfillment system is closed")
+	}
+	return err
+}
@@ -1,11 +1,12 @@
 package logic
 
 import (
+	"challenge/clock"
 	"challenge/config"
 	"challenge/entity"
+	"challenge/sensor"
 	"fmt"
 	"log"
-	"math/rand"
 	"sync"
 	"time"
 )
@@ -16,206 +17,458 @@ import (
 
 // FulfillmentSystem encapsulates our order processing logic.
 type FulfillmentSystem struct {
-	CoolerGroup *entity.StorageGroup // Storage for cold orders.
-	HeaterGroup *entity.StorageGroup // Storage for hot orders.
-	ShelfGroup  *entity.StorageGroup // Storage for room-temperature orders (and fallback).
-	Actions     []Action             // Log of actions performed.
-	aLock       sync.Mutex           // Protects the actions slice.
-	mutex       sync.Mutex           // Protects the PlaceOrder function
-	pickupLock  sync.Mutex           // Protects the PickupOrder function
+	CoolerGroup    *entity.StorageGroup              // Storage for cold orders.
+	HeaterGroup    *entity.StorageGroup              // Storage for hot orders.
+	ShelfGroup     *entity.StorageGroup              // Storage for room-temperature orders (and fallback).
+	Actions        []Action                          // Log of actions performed, appended only by the event loop.
+	commands       chan command                      // Commands for the event loop.
+	closed         chan struct{}                     // Closed to stop the event loop.
+	closeOnce      sync.Once                         // Guards closing the closed channel.
+	clock          clock.Clock                       // Source of the current time.
+	placement      string                            // Placement policy name.
+	discard        DiscardPolicy                     // Picks the order to discard to make room.
+	rescue         RescuePriority                    // Picks the order to move to a group its route prefers first.
+	ledger         *ledger                           // Order locations according to the action log.
+	sensors        map[string]*sensor.Sensor         // Simulated temperature sensors by unit name.
+	routes         map[string][]*entity.StorageGroup // Groups allowed per temperature, in order of preference.
+	discardGroup   *entity.StorageGroup              // Group where room is made by discarding.
+	reservationSeq int                               // Number of reservations made, for their IDs.
+	maxMoves       int                               // Automatic moves allowed per order; zero for no limit.
+	minDwell       time.Duration                     // Time an order stays put after a move before moving again.
+	moveCost       float64                           // Share of its remaining lifetime a move must gain.
+
+	traceDecisions    bool          // Record the decision behind every action.
+	debugInvariants   bool          // Check invariants after every command.
+	invariantMode     string        // What automatic invariant checks do with violations.
+	invariantInterval time.Duration // Period of invariant checks during RunHarness; zero disables them.
+}
+
+// Option customizes a FulfillmentSystem at construction.
+type Option func(*FulfillmentSystem)
+
+// WithClock makes the system read time from the given clock instead of the wall clock.
+func WithClock(c clock.Clock) Option {
+	return func(fs *FulfillmentSystem) {
+		fs.clock = c
+	}
 }
 
-// Action represents an event (place, move, pickup, discard) on an order.
+// Action represents an event (place, move, pickup, discard) on an order, with everything
+// known about it. It is reduced to the challenge client's format only on submission.
 type Action struct {
-	Timestamp int64  // Unix timestamp in microseconds.
-	OrderID   string // Order identifier.
-	Action    string // Action type.
+	Timestamp   int64         // Unix timestamp in microseconds.
+	OrderID     string        // Order identifier.
+	Action      string        // Action type.
+	Temperature string        // Temperature of the order.
+	Source      string        // Storage unit the order left; blank when placed.
+	Target      string        // Storage unit the order entered; blank when picked up or discarded.
+	Storage     string        // Storage unit holding the order: the target, or the source if there is none.
+	Freshness   time.Duration // Remaining freshness of the order when the action happened.
+	Decay       float64       // Rate the order used up freshness at after the action, relative to normal; zero counts as one.
+	Rule        string        // Rule that fired, or RuleOverride for an operator's action.
+	Reason      string        // Operator's reason; blank for automatic actions.
+	Operator    string        // Person who ordered the action by hand; blank for automatic actions.
+	Decision    *Decision     // Orders considered for the action; nil unless decisions are traced.
 }
 
 // NewFulfillmentSystem initializes the system based on a Config.
-func NewFulfillmentSystem(cfg config.FulfillmentConfig) *FulfillmentSystem {
-	// TODO: Should be better to use a factory pattern here if different types of storage diverge in initialisation.
-	coolers := &entity.StorageGroup{}
-	for i := 1; i <= cfg.NumCoolers; i++ {
-		name := fmt.Sprintf("Cooler-%d", i)
-		coolers.Storages = append(coolers.Storages, entity.NewStorage(name, cfg.CoolerCap))
-		log.Printf("Created cooler: %s", name)
-	}
-	heaters := &entity.StorageGroup{}
-	for i := 1; i <= cfg.NumHeaters; i++ {
-		name := fmt.Sprintf("Heater-%d", i)
-		heaters.Storages = append(heaters.Storages, entity.NewStorage(name, cfg.HeaterCap))
-		log.Printf("Created heater: %s", name)
-	}
-	shelves := &entity.StorageGroup{}
-	for i := 1; i <= cfg.NumShelves; i++ {
-		name := fmt.Sprintf("Shelf-%d", i)
-		shelves.Storages = append(shelves.Storages, entity.NewStorage(name, cfg.ShelfCap))
-		log.Printf("Created shelf: %s", name)
-	}
-	return &FulfillmentSystem{
-		CoolerGroup: coolers,
-		HeaterGroup: heaters,
-		ShelfGroup:  shelves,
-		Actions:     make([]Action, 0),
-		aLock:       sync.Mutex{},
-		mutex:       sync.Mutex{},
-		pickupLock:  sync.Mutex{},
+func NewFulfillmentSystem(cfg config.FulfillmentConfig, opts ...Option) *FulfillmentSystem {
+	placement := cfg.PlacementPolicy
+	if !placementPolicies[placement] {
+		if placement != "" {
+			log.Printf("Unknown placement policy %q, using %q", placement, PlacementIdealFirst)
+		}
+		placement = PlacementIdealFirst
+	}
+	discard, ok := LookupDiscardPolicy(cfg.DiscardPolicy)
+	if !ok {
+		if cfg.DiscardPolicy != "" {
+			log.Printf("Unknown discard policy %q, using %q", cfg.DiscardPolicy, DiscardLeastFresh)
+		}
+		discard = discardPolicies[DiscardLeastFresh]
+	}
+	rescue, ok := LookupRescuePriority(cfg.RescuePriority)
+	if !ok {
+		if cfg.RescuePriority != "" {
+			log.Printf("Unknown rescue priority %q, using %q", cfg.RescuePriority, RescueMostUrgent)
+		}
+		rescue = rescuePriorities[RescueMostUrgent]
+	}
+	invariantMode := cfg.InvariantMode
+	if !invariantModes[invariantMode] {
+		if invariantMode != "" {
+			log.Printf("Unknown invariant mode %q, using %q", invariantMode, InvariantsReport)
+		}
+		invariantMode = InvariantsReport
+	}
+	fs := &FulfillmentSystem{
+		Actions:   make([]Action, 0),
+		commands:  make(chan command),
+		closed:    make(chan struct{}),
+		clock:     clock.Real{},
+		placement: placement,
+		discard:   discard,
+		rescue:    rescue,
+		ledger:    newLedger(),
+		maxMoves:  cfg.MaxMovesPerOrder,
+		minDwell:  time.Duration(cfg.MinDwellMs) * time.Millisecond,
+		moveCost:  cfg.MoveCostFactor,
+
+		traceDecisions:    cfg.TraceDecisions,
+		debugInvariants:   cfg.DebugInvariants,
+		invariantMode:     invariantMode,
+		invariantInterval: time.Duration(cfg.InvariantIntervalMs) * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(fs)
+	}
+	sensors := fs.buildStorages(cfg)
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			log.Printf("Created %s: %s (capacity %d)", g.name, s.Name, s.Capacity)
+		}
+	}
+	fs.sensors = make(map[string]*sensor.Sensor)
+	for name, c := range sensors {
+		fs.sensors[name] = sensor.New(c, fs.clock.Now())
 	}
+	fs.sampleSensors()
+	go fs.loop()
+	return fs
 }
 
-// logAction records an action and prints it.
-func (fs *FulfillmentSystem) logAction(orderID, actionType string, executeTime time.Time) {
-	fs.aLock.Lock()
-	defer fs.aLock.Unlock()
-	action := Action{
-		Timestamp: executeTime.UnixMicro(),
-		OrderID:   orderID,
-		Action:    actionType,
+// buildStorages creates the storage groups of a configuration with their routes and
+// quotas, and returns the sensor configuration of every unit that has one.
+func (fs *FulfillmentSystem) buildStorages(cfg config.FulfillmentConfig) map[string]config.SensorConfig {
+	fit := cfg.FitStrategy
+	if !validFit(fit) {
+		if fit != "" {
+			log.Printf("Unknown fit strategy %q, using %q", fit, entity.FitFirst)
+		}
+		fit = entity.FitFirst
 	}
-	fs.Actions = append(fs.Actions, action)
-	log.Printf("Action: %-7s OrderID: %-8s Timestamp: %d", actionType, orderID, action.Timestamp)
+	layout := &unitLayout{fit: fit, names: make(map[string]bool), sensors: make(map[string]config.SensorConfig)}
+	fs.CoolerGroup = layout.group("Cooler", cfg.Coolers, cfg.NumCoolers, cfg.CoolerCap, cfg.Sensors[GroupCooler])
+	fs.HeaterGroup = layout.group("Heater", cfg.Heaters, cfg.NumHeaters, cfg.HeaterCap, cfg.Sensors[GroupHeater])
+	fs.ShelfGroup = layout.group("Shelf", cfg.Shelves, cfg.NumShelves, cfg.ShelfCap, cfg.Sensors[GroupShelf])
+	fs.buildRoutes(cfg.Compatibility, cfg.DiscardGroup)
+	setQuotas(fs.ShelfGroup, cfg.ShelfQuotas)
+	return layout.sensors
 }
 
-// PlaceOrder implements the core logic for storing an order.
-func (fs *FulfillmentSystem) PlaceOrder(order entity.Order) {
-	fs.mutex.Lock()         // Lock the function
-	defer fs.mutex.Unlock() // Ensure the lock is released when the function exits
+// UnitInfo describes a storage unit as configured.
+type UnitInfo struct {
+	Name         string
+	Group        string
+	Capacity     int
+	Temperatures []string // Temperatures whose routes include the unit's group, sorted.
+}
 
-	storedOrder := &entity.StoredOrder{
-		Order:    order,
-		PlacedAt: time.Now(), // Assuming you want to set the current time as the placement time
-	}
-	// For hot/cold orders, attempt ideal storage first.
-	if order.Temperature == config.TEMP_TYPE_HOT || order.Temperature == config.TEMP_TYPE_COLD {
-		var idealGroup *entity.StorageGroup
-		if order.Temperature == config.TEMP_TYPE_HOT {
-			idealGroup = fs.HeaterGroup
-		} else {
-			idealGroup = fs.CoolerGroup
+// Layout lists the storage units a configuration creates, coolers first, without starting
+// a fulfillment system.
+func Layout(cfg config.FulfillmentConfig) []UnitInfo {
+	fs := &FulfillmentSystem{}
+	fs.buildStorages(cfg)
+	var units []UnitInfo
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			units = append(units, UnitInfo{Name: s.Name, Group: g.name, Capacity: s.Capacity, Temperatures: fs.Accepts(g.name)})
 		}
-		if idealGroup.Add(storedOrder) {
-			fs.logAction(order.ID, config.ACTION_TYPE_PLACE, time.Now())
-			return
+	}
+	return units
+}
+
+// unitLayout collects the storage units of every group as they are created.
+type unitLayout struct {
+	fit     string                         // Fit strategy of every group.
+	names   map[string]bool                // Unit names taken so far.
+	sensors map[string]config.SensorConfig // Sensor of each unit that has one, by unit name.
+}
+
+// group creates the units of a group, from its unit list if configured or else as count
+// identical units of the given capacity. Units are named after kind and their position
+// unless configured otherwise; names already taken by another unit are made unique. Units
+// without a sensor of their own get the group's, if any.
+func (l *unitLayout) group(kind string, units []config.UnitConfig, count, capacity int, groupSensor *config.SensorConfig) *entity.StorageGroup {
+	if len(units) == 0 {
+		units = make([]config.UnitConfig, count)
+		for i := range units {
+			units[i].Capacity = capacity
 		}
-		// If ideal storage is full, try the shelf.
-		if fs.ShelfGroup.Add(storedOrder) {
-			fs.logAction(order.ID, config.ACTION_TYPE_PLACE, time.Now())
-			return
+	}
+	group := &entity.StorageGroup{Fit: l.fit}
+	for i, u := range units {
+		name := u.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", kind, i+1)
 		}
-		// After failing to add to ideal storage and initial shelf add...
-		if fs.ShelfGroup.IsFull() {
-			// Attempt to move orders before discarding
-			if fs.tryMoveFromShelfGroup(order.Temperature) {
-				if fs.ShelfGroup.Add(storedOrder) {
-					fs.logAction(order.ID, config.ACTION_TYPE_PLACE, time.Now())
-					return
-				}
-			}
+		if l.names[name] {
+			unique := fmt.Sprintf("%s-%d", name, i+1)
+			log.Printf("Duplicate storage name %q, using %q", name, unique)
+			name = unique
 		}
-		// If all else fails, discard an order from the shelf to make space.
-		log.Printf("Shelf is full, attempting to discard an order. Adding order: %s\n", order.ID)
-		if fs.ShelfGroup.IsFull() {
-			fs.discardOrderFromShelfGroup()
+		l.names[name] = true
+		if s := u.Sensor; s != nil {
+			l.sensors[name] = *s
+		} else if groupSensor != nil {
+			l.sensors[name] = *groupSensor
 		}
-		if fs.ShelfGroup.Add(storedOrder) {
-			fs.logAction(order.ID, config.ACTION_TYPE_PLACE, time.Now())
-			return
+		storage := entity.NewStorage(name, u.Capacity)
+		storage.Decay = u.DecayMultiplier
+		if !u.IsEnabled() {
+			storage.Status = entity.StatusOffline
 		}
-	} else {
-		// For room-temperature orders, use the shelf.
-		if fs.ShelfGroup.Add(storedOrder) {
-			fs.logAction(order.ID, config.ACTION_TYPE_PLACE, time.Now())
-			return
+		group.Storages = append(group.Storages, storage)
+	}
+	return group
+}
+
+// setQuotas applies per-temperature quotas to every unit of a group.
+func setQuotas(group *entity.StorageGroup, quotas map[string]config.QuotaConfig) {
+	if len(quotas) == 0 {
+		return
+	}
+	for _, s := range group.Storages {
+		s.Quotas = make(map[string]entity.Quota)
+		reserved := 0
+		for temp, q := range quotas {
+			s.Quotas[temp] = entity.Quota{Max: q.Max, Reserved: q.Reserved}
+			reserved += q.Reserved
 		}
-		if fs.ShelfGroup.IsFull() {
-			fs.discardOrderFromShelfGroup()
+		if reserved > s.Capacity {
+			log.Printf("%s reserves %d slots but only has %d", s.Name, reserved, s.Capacity)
 		}
-		if fs.ShelfGroup.Add(storedOrder) {
-			fs.logAction(order.ID, config.ACTION_TYPE_PLACE, time.Now())
+	}
+}
+
+func validFit(name string) bool {
+	for _, fit := range entity.FitStrategies {
+		if name == fit {
+			return true
+		}
+	}
+	return false
+}
+
+// logAction records an action on a stored order and prints it. from names the storage
+// a moved order left.
+func (fs *FulfillmentSystem) logAction(so *entity.StoredOrder, actionType, from, rule string, decision *Decision) {
+	fs.appendAction(fs.newAction(so, actionType, from, rule, decision))
+}
+
+// newAction describes an action on a stored order happening now.
+func (fs *FulfillmentSystem) newAction(so *entity.StoredOrder, actionType, from, rule string, decision *Decision) Action {
+	now := fs.clock.Now()
+	a := Action{
+		Timestamp:   now.UnixMicro(),
+		OrderID:     so.Order.ID,
+		Action:      actionType,
+		Temperature: so.Order.Temperature,
+		Storage:     so.Storage,
+		Freshness:   so.RemainingFreshnessAt(now),
+		Decay:       so.Decay,
+		Rule:        rule,
+		Decision:    decision,
+	}
+	switch actionType {
+	case config.ACTION_TYPE_PLACE:
+		a.Target = so.Storage
+	case config.ACTION_TYPE_MOVE:
+		a.Source, a.Target = from, so.Storage
+	default:
+		a.Source = so.Storage
+	}
+	return a
+}
+
+// appendAction adds an action to the log and prints it.
+func (fs *FulfillmentSystem) appendAction(action Action) {
+	fs.Actions = append(fs.Actions, action)
+	fs.ledger.record(action)
+	if action.Operator != "" {
+		log.Printf("Action: %-7s OrderID: %-8s Storage: %-10s Timestamp: %d Operator: %s Reason: %s", action.Action, action.OrderID, action.Storage, action.Timestamp, action.Operator, action.Reason)
+		return
+	}
+	log.Printf("Action: %-7s OrderID: %-8s Storage: %-10s Timestamp: %d Rule: %s", action.Action, action.OrderID, action.Storage, action.Timestamp, action.Rule)
+}
+
+// PlaceOrder stores an order, moving or discarding other orders if needed.
+func (fs *FulfillmentSystem) PlaceOrder(order entity.Order) {
+	fs.do(func() { fs.placeOrder(order) })
+}
+
+// placeOrder implements the core logic for storing an order.
+func (fs *FulfillmentSystem) placeOrder(order entity.Order) {
+	storedOrder := &entity.StoredOrder{
+		Order:    order,
+		PlacedAt: fs.clock.Now(), // Assuming you want to set the current time as the placement time
+	}
+	// Try the groups of the order's route in order of preference.
+	route := fs.route(order.Temperature)
+	for i, group := range route {
+		if group.Add(storedOrder) {
+			rule := RuleIdeal
+			if i > 0 {
+				rule = RuleIdealFull
+			}
+			fs.logAction(storedOrder, config.ACTION_TYPE_PLACE, "", rule, nil)
 			return
 		}
 	}
+	// Every group is full: make room in the route's fallback.
+	fallback := fs.fallback(order.Temperature)
+	if !fallback.Fits(order) {
+		// Attempt to move orders before discarding
+		if fs.tryRescueFrom(fallback, order.Temperature, order.ShelfLife()) {
+			if fallback.Add(storedOrder) {
+				fs.logAction(storedOrder, config.ACTION_TYPE_PLACE, "", RuleFallbackFull, nil)
+				return
+			}
+		}
+	}
+	// If all else fails, discard an order from the fallback to make space.
+	log.Printf("No room for order %s, attempting to discard an order from the %s group\n", order.ID, fs.groupName(fallback))
+	if !fs.makeRoom(fallback, order) || !fallback.Add(storedOrder) {
+		// Nothing can make room, such as for an order larger than any unit or a group full
+		// of pinned orders: the order is discarded on arrival.
+		log.Printf("No room can be made for order %s, discarding it\n", order.ID)
+		fs.logAction(storedOrder, config.ACTION_TYPE_DISCARD, "", RuleRejected, nil)
+		return
+	}
+	fs.logAction(storedOrder, config.ACTION_TYPE_PLACE, "", RuleFallbackFull, nil)
 }
 
 // PickupOrder removes an order from any storage group.
 func (fs *FulfillmentSystem) PickupOrder(orderID string) {
-	fs.pickupLock.Lock()         // Lock the function
-	defer fs.pickupLock.Unlock() // Ensure the lock is released when the function exits
+	fs.do(func() { fs.pickupOrder(orderID) })
+}
 
-	if so, ok := fs.HeaterGroup.Remove(orderID); ok {
-		fs.logAction(so.Order.ID, config.ACTION_TYPE_PICKUP, time.Now())
-		return
+func (fs *FulfillmentSystem) pickupOrder(orderID string) {
+	if !fs.removeOrder(orderID, config.ACTION_TYPE_PICKUP, RulePickup) {
+		log.Printf("Order %s not found during pickup", orderID)
 	}
-	if so, ok := fs.CoolerGroup.Remove(orderID); ok {
-		fs.logAction(so.Order.ID, config.ACTION_TYPE_PICKUP, time.Now())
-		return
+}
+
+// CancelOrder removes a cancelled order from storage, recording it as discarded.
+func (fs *FulfillmentSystem) CancelOrder(orderID string) {
+	fs.do(func() { fs.cancelOrder(orderID) })
+}
+
+func (fs *FulfillmentSystem) cancelOrder(orderID string) {
+	if !fs.removeOrder(orderID, config.ACTION_TYPE_DISCARD, RuleCancelled) {
+		log.Printf("Order %s not found during cancellation", orderID)
 	}
-	if so, ok := fs.ShelfGroup.Remove(orderID); ok {
-		fs.logAction(so.Order.ID, config.ACTION_TYPE_PICKUP, time.Now())
-		return
+}
+
+// removeOrder takes an order out of whichever group holds it and logs the removal for
+// the given rule. Orders waiting for space in that group, stored in a group their route
+// ranks lower, are moved in immediately.
+func (fs *FulfillmentSystem) removeOrder(orderID, actionType, rule string) bool {
+	for _, g := range fs.groups() {
+		if so, ok := g.group.Remove(orderID); ok {
+			fs.logAction(so, actionType, "", rule, nil)
+			fs.releaseReservations(orderID)
+			fs.refill(g.group)
+			return true
+		}
 	}
-	log.Printf("Order %s not found during pickup", orderID)
+	return false
 }
 
 // RunHarness processes orders at the given rate and schedules pickups after a random delay.
 func (fs *FulfillmentSystem) RunHarness(orders []entity.Order, orderInterval, minPickup, maxPickup time.Duration) {
-	var wg sync.WaitGroup
-	// Implement the background reallocation to automatically MOVE or DISCARD orders.
-	stopRealloc := make(chan struct{})
-	// Start background reallocation.
-	go fs.ReallocateOrders(stopRealloc)
-	for _, order := range orders {
-		wg.Add(1)
-		go func(ord entity.Order) {
-			defer wg.Done()
-			fs.PlaceOrder(ord)
-			// Simulate pickup after a random delay between minPickup and maxPickup.
-			delay := minPickup + time.Duration(rand.Int63n(int64(maxPickup-minPickup)))
-			time.Sleep(delay)
-			fs.PickupOrder(ord.ID)
-		}(order)
-		time.Sleep(orderInterval)
-	}
-	wg.Wait()
-	//close(stopRealloc)
+	fs.StreamHarness(Stream(orders), HarnessOptions{
+		OrderInterval: orderInterval,
+		MinPickup:     minPickup,
+		MaxPickup:     maxPickup,
+		Seed:          time.Now().UnixNano(),
+	})
 }
 
-// discardOrderFromShelfGroup selects the order with the lowest remaining freshness and discards it.
-func (fs *FulfillmentSystem) discardOrderFromShelfGroup() {
-	candidate, found := fs.ShelfGroup.GetLeastFreshOrder()
+// makeRoom frees slots in a group until one of its units can hold the order, moving its
+// orders to groups their routes prefer or discarding them. Quotas are respected: only
+// orders whose removal frees a slot the order may use are discarded, and pinned orders
+// never are. Once an order has been discarded from a unit, further discards come from
+// that unit, so a large order never empties several shelves when clearing one would do.
+// It returns false if the order cannot fit in any unit of the group, even an empty one.
+func (fs *FulfillmentSystem) makeRoom(group *entity.StorageGroup, order entity.Order) bool {
+	if !group.CanHold(order) {
+		log.Printf("Order %s needs %d slots, more than any %s unit has", order.ID, order.Units(), fs.groupName(group))
+		return false
+	}
+	target := ""
+	for !group.Fits(order) {
+		var candidates []*entity.StoredOrder
+		for _, storage := range group.Storages {
+			if !storage.CanHold(order) || (target != "" && storage.Name != target) {
+				continue
+			}
+			// Only orders whose removal frees a slot the order may use are worth discarding,
+			// and only in their own fallback group
+			for _, so := range storage.ListOrders() {
+				if !so.Pinned && storage.Helps(so.Order, order) && fs.fallback(so.Order.Temperature) == group {
+					candidates = append(candidates, so)
+				}
+			}
+		}
+		discarded, ok := fs.discardOrderFrom(group, candidates)
+		if !ok {
+			return false
+		}
+		if discarded != nil {
+			target = discarded.Storage
+		}
+	}
+	return true
+}
+
+// discardOrderFrom selects an order of a group among the candidates with the configured
+// discard policy and discards it, unless moving an order of the group to a group its route
+// prefers makes room instead. It returns the discarded order, nil if an order was moved,
+// and false if neither was possible.
+func (fs *FulfillmentSystem) discardOrderFrom(group *entity.StorageGroup, candidates []*entity.StoredOrder) (*entity.StoredOrder, bool) {
+	candidate, found := fs.discard(candidates, fs.clock.Now())
 	if !found {
-		return
+		return nil, false
 	}
 	// Try moving an order before discarding
-	if fs.tryMoveFromShelfGroup(candidate.Order.Temperature) {
-		return // Order successfully moved, no need to discard
+	saved := fs.lifetime(candidate, candidate.Decay)
+	if fs.tryRescueFrom(group, candidate.Order.Temperature, saved) {
+		return nil, true // Order successfully moved, no need to discard
+	}
+	if fs.placement == PlacementRescueAny {
+		if fs.tryRescueFrom(group, "", saved) {
+			return nil, true
+		}
 	}
 	// If no order could be moved, proceed with discarding
-	if _, ok := fs.ShelfGroup.Remove(candidate.Order.ID); ok {
-		fs.logAction(candidate.Order.ID, config.ACTION_TYPE_DISCARD, time.Now())
+	if _, ok := group.Remove(candidate.Order.ID); !ok {
+		return nil, false
 	}
+	fs.logAction(candidate, config.ACTION_TYPE_DISCARD, "", RuleFallbackFull, fs.decide(candidates))
+	fs.releaseReservations(candidate.Order.ID)
+	return candidate, true
 }
 
-func (fs *FulfillmentSystem) tryMoveFromShelfGroup(temp string) bool {
-	var idealGroup *entity.StorageGroup
-	if temp == config.TEMP_TYPE_HOT {
-		idealGroup = fs.HeaterGroup
-	} else if temp == config.TEMP_TYPE_COLD {
-		idealGroup = fs.CoolerGroup
-	} else {
-		return false
+// tryRescueFrom moves one order of the given temperature, or of any temperature if blank,
+// out of a group into a group its route prefers, reporting whether it could. The room it
+// makes saves freshness worth saved, which is weighed against the cost of the move.
+func (fs *FulfillmentSystem) tryRescueFrom(group *entity.StorageGroup, temp string, saved time.Duration) bool {
+	var candidates []*entity.StoredOrder
+	for _, so := range group.ListOrders() {
+		if !so.Pinned && (temp == "" || so.Order.Temperature == temp) {
+			candidates = append(candidates, so)
+		}
 	}
-
-	orders := fs.ShelfGroup.ListOrders()
-	for _, so := range orders {
-		if so.Order.Temperature == temp {
-			for _, shelf := range fs.ShelfGroup.Storages {
-				moved := fs.atomicMoveOrder(so.Order.ID, shelf, idealGroup)
-				if moved {
-					fs.logAction(so.Order.ID, config.ACTION_TYPE_MOVE, time.Now())
-					return true
-				}
+	for _, so := range candidates {
+		source, current := fs.findUnit(so.Storage)
+		for _, better := range fs.route(so.Order.Temperature) {
+			if better == current {
+				break
+			}
+			if fs.mayMove(so, better, saved) && fs.atomicMoveOrder(so.Order.ID, source, better) {
+				fs.logAction(so, config.ACTION_TYPE_MOVE, source.Name, RuleMovedInstead, fs.decide(candidates))
+				return true
 			}
 		}
 	}
@@ -231,71 +484,91 @@ func (fs *FulfillmentSystem) atomicMoveOrder(orderID string, source *entity.Stor
 		return false
 	}
 
-	// If the order is currently not stored under ideal conditions (i.e., stored on the shelf), update the remaining freshness.
-	// Note: Only hot/cold orders require this treatment, room temperature does not.
-	if order.Order.Temperature != config.TEMP_TYPE_ROOM {
-		// Calculate the time t the order has been stored on the shelf
-		t := time.Since(order.PlacedAt)
-		// Storage under non-ideal conditions consumes freshness at twice the ideal rate
-		newRemaining := order.Order.InitialFreshness - 2*t
-		if newRemaining <= 0 {
-			// The order has expired, do not move
-			source.Lock.Unlock()
-			return false
-		}
-		// Update the order's placement time and freshness to the remaining ideal freshness after moving
-		order.PlacedAt = time.Now()
-		order.Order.Freshness = newRemaining
-	}
-	// Try to add the order to one of the storages in the destination group
-	for _, destStorage := range destination.Storages {
-		// Lock the destination storage
-		destStorage.Lock.Lock()
-		if len(destStorage.Orders) < destStorage.Capacity {
-			// Remove the order from the source
-			delete(source.Orders, orderID)
-			// Add to the destination storage
-			destStorage.Orders[orderID] = order
-			destStorage.Lock.Unlock()
-			source.Lock.Unlock()
-			return true
-		}
-		destStorage.Lock.Unlock()
+	now := fs.clock.Now()
+	if order.RemainingFreshnessAt(now) <= 0 {
+		// The order has expired, do not move
+		source.Lock.Unlock()
+		return false
+	}
+	// Add the order to the storage the destination group picks for it
+	destStorage := destination.Pick(order.Order)
+	if destStorage == nil {
+		source.Lock.Unlock()
+		return false
 	}
+	destStorage.Lock.Lock()
+	// Remove the order from the source
+	source.Remove(orderID)
+	// The order keeps its remaining freshness, including what it used up before earlier
+	// moves, and decays at the destination's rate from now on. Recomputing it from the
+	// initial freshness and the time since the latest placement would credit that back.
+	order.Rebase(now)
+	order.Moves++
+	order.MovedAt = now
+	destination.Store(destStorage, order, false)
+	destStorage.Lock.Unlock()
 	source.Lock.Unlock()
-	return false
+	return true
 }
 
+// reallocInterval is the period of ReallocateOrders.
+const reallocInterval = time.Second
+
+// ReallocateOrders runs Reallocate every second until stop is closed. Freed space is
+// refilled as soon as an order leaves it, so this is only a fallback for moves that
+// could not happen at that moment.
 func (fs *FulfillmentSystem) ReallocateOrders(stop <-chan struct{}) {
-	ticker := time.NewTicker(1 * time.Second)
+	ticker := time.NewTicker(reallocInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			// Only attempt reallocation if the shelf is full.
-			if !fs.ShelfGroup.IsFull() {
-				continue
-			}
-			shelfOrders := fs.ShelfGroup.ListOrders()
-			for _, so := range shelfOrders {
-				if so.Order.Temperature == config.TEMP_TYPE_HOT && !fs.HeaterGroup.IsFull() {
-					for _, shelf := range fs.ShelfGroup.Storages {
-						if fs.atomicMoveOrder(so.Order.ID, shelf, fs.HeaterGroup) {
-							fs.logAction(so.Order.ID, config.ACTION_TYPE_MOVE, time.Now())
-							break
-						}
-					}
-				} else if so.Order.Temperature == config.TEMP_TYPE_COLD && !fs.CoolerGroup.IsFull() {
-					for _, shelf := range fs.ShelfGroup.Storages {
-						if fs.atomicMoveOrder(so.Order.ID, shelf, fs.CoolerGroup) {
-							fs.logAction(so.Order.ID, config.ACTION_TYPE_MOVE, time.Now())
-							break
-						}
-					}
-				}
-			}
+			fs.Reallocate()
 		case <-stop:
 			return
 		}
 	}
 }
+
+// Reallocate makes a single pass moving orders out of degraded units where there is room,
+// then orders into the groups their routes prefer.
+func (fs *FulfillmentSystem) Reallocate() {
+	fs.do(fs.reallocate)
+}
+
+func (fs *FulfillmentSystem) reallocate() {
+	for _, g := range fs.groups() {
+		fs.refill(g.group)
+	}
+}
+
+// refill moves orders into a group while it has room, from groups their routes rank
+// lower, in the order of the configured rescue priority. Degraded units are drained
+// first, so their orders take room as soon as it appears.
+func (fs *FulfillmentSystem) refill(group *entity.StorageGroup) {
+	fs.drainDegraded()
+	var candidates []*entity.StoredOrder
+	for _, g := range fs.groups() {
+		if g.group == group {
+			continue
+		}
+		for _, so := range g.group.ListOrders() {
+			if !so.Pinned && fs.prefers(so.Order.Temperature, group, g.group) {
+				candidates = append(candidates, so)
+			}
+		}
+	}
+	for len(candidates) > 0 && !group.IsFull() {
+		so, _ := fs.rescue(candidates, fs.clock.Now())
+		decision := fs.decide(candidates)
+		for i, c := range candidates {
+			if c == so {
+				candidates = append(candidates[:i], candidates[i+1:]...)
+				break
+			}
+		}
+		if source, _ := fs.findUnit(so.Storage); source != nil && fs.mayMove(so, group, 0) && fs.atomicMoveOrder(so.Order.ID, source, group) {
+			fs.logAction(so, config.ACTION_TYPE_MOVE, source.Name, RuleRefill, decision)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package logic
+
+import (
+	"challenge/arrival"
+	"challenge/clock"
+	"challenge/entity"
+	"container/heap"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultHarnessWorkers is the number of harness workers used when none is configured.
+const DefaultHarnessWorkers = 4
+
+// HarnessOptions configures StreamHarness.
+type HarnessOptions struct {
+	OrderInterval time.Duration   // Time between consecutive placements when Arrivals is nil.
+	Arrivals      arrival.Model   // Arrival process deciding when orders are taken from the stream.
+	MinPickup     time.Duration   // Shortest delay between placing an order and picking it up.
+	MaxPickup     time.Duration   // Longest delay between placing an order and picking it up.
+	Workers       int             // Goroutines running place and pickup operations; DefaultHarnessWorkers if zero.
+	MaxPending    int             // Orders awaiting pickup before intake pauses; zero means unbounded.
+	Seed          int64           // Seed of the pickup delays and, through arrival.Seed, of the arrivals.
+	PickupDelays  []time.Duration // Delay of each order in stream order, used instead of a drawn one while they last.
+}
+
+// HarnessStats counts what a harness run did.
+type HarnessStats struct {
+	Placed      int // Orders taken from the stream and placed.
+	PickedUp    int // Pickups performed.
+	PeakPending int // Largest number of orders awaiting pickup at once.
+}
+
+// pickup is a scheduled pickup of an order.
+type pickup struct {
+	at      time.Time
+	seq     int // Order in which pickups were scheduled, breaking ties between equal times.
+	orderID string
+}
+
+// pickupHeap is a min-heap of scheduled pickups by time.
+type pickupHeap []pickup
+
+func (h pickupHeap) Len() int { return len(h) }
+func (h pickupHeap) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h pickupHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pickupHeap) Push(x interface{}) { *h = append(*h, x.(pickup)) }
+func (h *pickupHeap) Pop() interface{} {
+	old := *h
+	p := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return p
+}
+
+// StreamHarness places orders read from a stream until it is closed, as they arrive
+// according to the arrival model, and picks each one up after a random delay between
+// MinPickup and MaxPickup. Pickups wait on a single timer
+// heap, and operations run on a fixed pool of workers, all operations on one order on
+// the same worker so a pickup never overtakes its placement. Intake applies
+// back-pressure: reading from the stream pauses while the workers are saturated or
+// MaxPending orders await pickup. It returns once every order has been picked up.
+//
+// Time is read from the system's clock. On a clock.Stepped clock, such as a virtual one,
+// each operation runs to completion before the next, and instead of waiting the harness
+// moves the clock to the next arrival, pickup, reallocation or invariant check, which
+// makes runs deterministic for a seed.
+func (fs *FulfillmentSystem) StreamHarness(orders <-chan entity.Order, opts HarnessOptions) HarnessStats {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultHarnessWorkers
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	delay := func(i int) time.Duration {
+		if i < len(opts.PickupDelays) {
+			return opts.PickupDelays[i]
+		}
+		spread := int64(opts.MaxPickup - opts.MinPickup)
+		if spread <= 0 {
+			return opts.MinPickup
+		}
+		return opts.MinPickup + time.Duration(rng.Int63n(spread))
+	}
+	model := opts.Arrivals
+	if model == nil {
+		model = arrival.Constant{Interval: opts.OrderInterval}
+	}
+	arrivals := model.Start(rand.New(rand.NewSource(arrival.Seed(opts.Seed))))
+
+	queues := make([]chan func(), workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan func(), 1)
+		wg.Add(1)
+		go func(jobs <-chan func()) {
+			defer wg.Done()
+			for job := range jobs {
+				job()
+			}
+		}(queues[i])
+	}
+	stepped, virtual := fs.clock.(clock.Stepped)
+	submit := func(orderID string, job func()) {
+		h := fnv.New32a()
+		h.Write([]byte(orderID))
+		queue := queues[h.Sum32()%uint32(workers)]
+		if !virtual {
+			queue <- job
+			return
+		}
+		done := make(chan struct{})
+		queue <- func() {
+			defer close(done)
+			job()
+		}
+		<-done
+	}
+
+	// Implement the background reallocation to automatically MOVE or DISCARD orders. On a
+	// stepped clock it runs, like the invariant checks, when the clock reaches its tick.
+	stop := make(chan struct{})
+	if !virtual {
+		go fs.ReallocateOrders(stop)
+		if fs.invariantInterval > 0 {
+			go fs.CheckInvariantsEvery(fs.invariantInterval, stop)
+		}
+	}
+
+	var stats HarnessStats
+	pending := &pickupHeap{}
+	timer := time.NewTimer(time.Hour)
+	in := orders
+	start := fs.clock.Now()
+	nextIntake := start.Add(arrivals())
+	nextRealloc := start.Add(reallocInterval)
+	nextCheck := start.Add(fs.invariantInterval)
+	place := func(order entity.Order) {
+		submit(order.ID, func() { fs.PlaceOrder(order) })
+		heap.Push(pending, pickup{at: fs.clock.Now().Add(delay(stats.Placed)), seq: stats.Placed, orderID: order.ID})
+		stats.Placed++
+		stats.PeakPending = max(stats.PeakPending, pending.Len())
+		nextIntake = start.Add(arrivals())
+	}
+	for {
+		now := fs.clock.Now()
+		for pending.Len() > 0 && !(*pending)[0].at.After(now) {
+			p := heap.Pop(pending).(pickup)
+			submit(p.orderID, func() { fs.PickupOrder(p.orderID) })
+			stats.PickedUp++
+		}
+		open := in != nil && (opts.MaxPending <= 0 || pending.Len() < opts.MaxPending)
+		if virtual {
+			// Pickups due now go first, then placements, then the ticks.
+			if open && !nextIntake.After(now) {
+				if order, ok := <-in; ok {
+					place(order)
+				} else {
+					in = nil
+				}
+				continue
+			}
+			if !nextRealloc.After(now) {
+				fs.Reallocate()
+				nextRealloc = nextRealloc.Add(reallocInterval)
+			}
+			if fs.invariantInterval > 0 && !nextCheck.After(now) {
+				fs.do(func() { fs.enforceInvariants(fs.invariantMode) })
+				nextCheck = nextCheck.Add(fs.invariantInterval)
+			}
+		}
+		if in == nil && pending.Len() == 0 {
+			break
+		}
+
+		var intake <-chan entity.Order
+		wait := time.Duration(-1)
+		if open {
+			if d := nextIntake.Sub(now); d > 0 {
+				wait = d
+			} else {
+				intake = in
+			}
+		}
+		if pending.Len() > 0 {
+			if d := (*pending)[0].at.Sub(now); wait < 0 || d < wait {
+				wait = d
+			}
+		}
+		if virtual {
+			next := nextRealloc
+			if wait >= 0 {
+				next = now.Add(min(wait, next.Sub(now)))
+			}
+			if fs.invariantInterval > 0 && nextCheck.Before(next) {
+				next = nextCheck
+			}
+			stepped.Set(next)
+			continue
+		}
+		if wait < 0 {
+			// Nothing is scheduled; wait for the stream.
+			wait = time.Hour
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case order, ok := <-intake:
+			if !ok {
+				in = nil
+				continue
+			}
+			place(order)
+		case <-timer.C:
+		}
+	}
+	timer.Stop()
+
+	for _, q := range queues {
+		close(q)
+	}
+	wg.Wait()
+	close(stop)
+	return stats
+}
+
+// Stream feeds a slice of orders to StreamHarness.
+func Stream(orders []entity.Order) <-chan entity.Order {
+	ch := make(chan entity.Order)
+	go func() {
+		defer close(ch)
+		for _, o := range orders {
+			ch <- o
+		}
+	}()
+	return ch
+}
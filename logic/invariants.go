@@ -0,0 +1,219 @@
+package logic
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Invariant check modes, selecting what automatic checks do with violations.
+const (
+	InvariantsOff    = "off"    // Skip automatic checks.
+	InvariantsReport = "report" // Log violations with a state dump.
+	InvariantsPanic  = "panic"  // Panic with a state dump.
+)
+
+var invariantModes = map[string]bool{InvariantsOff: true, InvariantsReport: true, InvariantsPanic: true}
+
+// invariantDumpActions is the number of most recent actions included in a state dump.
+const invariantDumpActions = 10
+
+// InvariantViolation describes a broken storage consistency rule.
+type InvariantViolation struct {
+	Rule    string // Short name of the broken rule.
+	Message string
+}
+
+func (v InvariantViolation) Error() string {
+	return v.Rule + ": " + v.Message
+}
+
+// ledger tracks where the action log says every order is, so storages can be checked
+// against the log without replaying it.
+type ledger struct {
+	locations map[string]string // Order ID to the storage named by its latest action.
+	finished  map[string]bool   // Orders picked up or discarded.
+	last      int64             // Timestamp of the latest action.
+	anomalies []InvariantViolation
+}
+
+func newLedger() *ledger {
+	return &ledger{locations: make(map[string]string), finished: make(map[string]bool)}
+}
+
+// record applies an action to the ledger, noting actions the log cannot explain.
+func (l *ledger) record(a Action) {
+	if a.Timestamp < l.last {
+		l.note("monotonic-timestamps", "%s of order %s at %d is before the previous action at %d", a.Action, a.OrderID, a.Timestamp, l.last)
+	}
+	l.last = max(l.last, a.Timestamp)
+	if l.finished[a.OrderID] {
+		l.note("log-consistency", "%s of order %s after it left the system", a.Action, a.OrderID)
+	}
+	current, stored := l.locations[a.OrderID]
+	if stored && a.Source != "" && a.Source != current {
+		l.note("log-consistency", "%s of order %s from %s while it is in %s", a.Action, a.OrderID, a.Source, current)
+	}
+	switch a.Action {
+	case config.ACTION_TYPE_PLACE:
+		if stored {
+			l.note("log-consistency", "order %s placed twice", a.OrderID)
+		}
+		l.locations[a.OrderID] = a.Storage
+	case config.ACTION_TYPE_MOVE:
+		if !stored {
+			l.note("log-consistency", "move of order %s that was never placed", a.OrderID)
+		}
+		l.locations[a.OrderID] = a.Storage
+	case config.ACTION_TYPE_PIN, config.ACTION_TYPE_UNPIN:
+		if !stored {
+			l.note("log-consistency", "%s of order %s that is not stored", a.Action, a.OrderID)
+		}
+	case config.ACTION_TYPE_PICKUP, config.ACTION_TYPE_DISCARD:
+		// Orders rejected on arrival are discarded without a storage.
+		if !stored && (a.Action == config.ACTION_TYPE_PICKUP || a.Storage != "") {
+			l.note("log-consistency", "%s of order %s that was never placed", a.Action, a.OrderID)
+		}
+		delete(l.locations, a.OrderID)
+		l.finished[a.OrderID] = true
+	}
+}
+
+func (l *ledger) note(rule, format string, args ...interface{}) {
+	l.anomalies = append(l.anomalies, InvariantViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// CheckInvariants verifies storage consistency and returns every violation found:
+// no storage holds orders taking more slots than its capacity, each storage's used slot
+// count matches its orders, every order ID is stored at most once across all groups,
+// each stored order knows the storage holding it, no temperature exceeds its quota,
+// offline storages hold only pinned orders, the storages agree with the action log, and
+// the action log's timestamps never decrease.
+func (fs *FulfillmentSystem) CheckInvariants() []InvariantViolation {
+	var violations []InvariantViolation
+	if !fs.do(func() { violations = fs.checkInvariants() }) {
+		violations = fs.checkInvariants()
+	}
+	return violations
+}
+
+func (fs *FulfillmentSystem) checkInvariants() []InvariantViolation {
+	violations := append([]InvariantViolation(nil), fs.ledger.anomalies...)
+	fs.ledger.anomalies = nil
+	report := func(rule, format string, args ...interface{}) {
+		violations = append(violations, InvariantViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]string)
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			used := 0
+			for _, so := range s.Orders {
+				used += so.Order.Units()
+			}
+			if used > s.Capacity {
+				report("capacity", "%s holds %d slots of orders, over its capacity of %d", s.Name, used, s.Capacity)
+			}
+			for temp, q := range s.Quotas {
+				if q.Max > 0 && s.UsedBy(temp) > q.Max {
+					report("quota", "%s holds %d slots of %s orders, over its quota of %d", s.Name, s.UsedBy(temp), temp, q.Max)
+				}
+			}
+			if s.Status == entity.StatusOffline {
+				for id, so := range s.Orders {
+					if !so.Pinned {
+						report("offline-empty", "%s is offline but holds order %s", s.Name, id)
+					}
+				}
+			}
+			if used != s.Used() {
+				report("used-volume", "%s counts %d used slots but its orders take %d", s.Name, s.Used(), used)
+			}
+			for id, so := range s.Orders {
+				if so.Order.ID != id {
+					report("order-identity", "%s stores order %s under ID %s", s.Name, so.Order.ID, id)
+				}
+				if so.Storage != s.Name {
+					report("order-location", "order %s in %s believes it is in %s", id, s.Name, so.Storage)
+				}
+				if other, dup := seen[id]; dup {
+					report("unique-ids", "order %s is stored in both %s and %s", id, other, s.Name)
+				}
+				seen[id] = s.Name
+				if logged, ok := fs.ledger.locations[id]; !ok {
+					report("log-consistency", "order %s is in %s but the action log has it out of the system", id, s.Name)
+				} else if logged != s.Name {
+					report("log-consistency", "order %s is in %s but the action log has it in %s", id, s.Name, logged)
+				}
+			}
+		}
+	}
+	for id, logged := range fs.ledger.locations {
+		if _, ok := seen[id]; !ok {
+			report("log-consistency", "action log has order %s in %s but no storage holds it", id, logged)
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Rule < violations[j].Rule })
+	return violations
+}
+
+// afterCommand runs the invariant check of debug mode after every command.
+func (fs *FulfillmentSystem) afterCommand() {
+	if fs.debugInvariants {
+		fs.enforceInvariants(fs.invariantMode)
+	}
+}
+
+// enforceInvariants checks invariants and reports or panics according to the mode.
+func (fs *FulfillmentSystem) enforceInvariants(mode string) {
+	if mode == InvariantsOff {
+		return
+	}
+	violations := fs.checkInvariants()
+	if len(violations) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d invariant violation(s):\n", len(violations))
+	for _, v := range violations {
+		fmt.Fprintf(&b, "  %v\n", v)
+	}
+	b.WriteString(fs.stateDump())
+	if mode == InvariantsPanic {
+		panic(b.String())
+	}
+	log.Print(b.String())
+}
+
+// stateDump renders the storages and the latest actions for violation reports.
+func (fs *FulfillmentSystem) stateDump() string {
+	var b strings.Builder
+	if data, err := fs.snapshot().JSON(); err == nil {
+		fmt.Fprintf(&b, "State:\n%s\n", data)
+	}
+	from := max(0, len(fs.Actions)-invariantDumpActions)
+	fmt.Fprintf(&b, "Latest actions:\n")
+	for _, a := range fs.Actions[from:] {
+		fmt.Fprintf(&b, "  %+v\n", a)
+	}
+	return b.String()
+}
+
+// CheckInvariantsEvery checks invariants at every interval until stop is closed, reporting
+// or panicking according to the configured mode.
+func (fs *FulfillmentSystem) CheckInvariantsEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fs.do(func() { fs.enforceInvariants(fs.invariantMode) })
+		case <-stop:
+			return
+		}
+	}
+}
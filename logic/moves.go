@@ -0,0 +1,51 @@
+package logic
+
+import (
+	"challenge/entity"
+	"log"
+	"time"
+)
+
+// mayMove reports whether the move limits let an order move into a group: it has moves
+// left, has stayed put for the minimum dwell time since its last move, and the move gains
+// enough freshness to be worth its cost. Moving into a storage that decays the order more
+// slowly gains the difference in remaining lifetime, and room the move makes saves
+// freshness worth saved; the cost is the move cost factor times the order's remaining
+// lifetime.
+func (fs *FulfillmentSystem) mayMove(so *entity.StoredOrder, dest *entity.StorageGroup, saved time.Duration) bool {
+	now := fs.clock.Now()
+	if fs.maxMoves > 0 && so.Moves >= fs.maxMoves {
+		log.Printf("Order %s was moved %d times, leaving it in %s", so.Order.ID, so.Moves, so.Storage)
+		return false
+	}
+	if fs.minDwell > 0 && !so.MovedAt.IsZero() && now.Sub(so.MovedAt) < fs.minDwell {
+		log.Printf("Order %s was moved %v ago, leaving it in %s", so.Order.ID, now.Sub(so.MovedAt), so.Storage)
+		return false
+	}
+	if fs.moveCost <= 0 {
+		return true
+	}
+	storage := dest.Pick(so.Order)
+	if storage == nil {
+		return false
+	}
+	current := fs.lifetime(so, so.Decay)
+	gain := fs.lifetime(so, storage.DecayRateFor(so.Order.Temperature)) - current + saved
+	if float64(gain) < fs.moveCost*float64(current) {
+		log.Printf("Moving order %s to %s gains %v, not worth it", so.Order.ID, storage.Name, gain)
+		return false
+	}
+	return true
+}
+
+// lifetime returns how long an order has until it expires if it decays at rate from now on.
+func (fs *FulfillmentSystem) lifetime(so *entity.StoredOrder, rate float64) time.Duration {
+	remaining := so.RemainingFreshnessAt(fs.clock.Now())
+	if remaining <= 0 {
+		return 0
+	}
+	if rate <= 0 {
+		return remaining
+	}
+	return time.Duration(float64(remaining) / rate)
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"fmt"
+	"log"
+)
+
+// SetUnitStatus changes the status of the named storage unit. Taking a unit offline
+// evacuates its orders at once: to other units of its group, then to the other groups of
+// their routes in order of preference, discarding by the configured policy to make room
+// in the route's fallback group and discarding the order itself as a last resort. A
+// degraded unit takes no new orders and its orders are moved out whenever room appears
+// elsewhere, but nothing is discarded for them. Bringing a unit back online re-admits
+// it, refilling it from the shelf.
+func (fs *FulfillmentSystem) SetUnitStatus(name, status string) error {
+	var err error
+	if !fs.do(func() { err = fs.setUnitStatus(name, status) }) {
+		return fmt.Errorf("fulfillment system is closed")
+	}
+	return err
+}
+
+func (fs *FulfillmentSystem) setUnitStatus(name, status string) error {
+	if status != entity.StatusOnline && status != entity.StatusDegraded && status != entity.StatusOffline {
+		return fmt.Errorf("unknown status %q (want %s, %s or %s)", status, entity.StatusOnline, entity.StatusDegraded, entity.StatusOffline)
+	}
+	storage, group := fs.findUnit(name)
+	if storage == nil {
+		return fmt.Errorf("unknown storage unit %q", name)
+	}
+	storage.Status = status
+	log.Printf("Storage %s is now %s", name, status)
+	if status == entity.StatusOnline {
+		fs.reallocate()
+	} else {
+		fs.evacuate(storage, group, status == entity.StatusOffline)
+	}
+	return nil
+}
+
+// findUnit returns the named storage unit and its group, or nils if there is none.
+func (fs *FulfillmentSystem) findUnit(name string) (*entity.Storage, *entity.StorageGroup) {
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			if s.Name == name {
+				return s, g.group
+			}
+		}
+	}
+	return nil, nil
+}
+
+// evacuate moves the orders of a unit that no longer takes orders to other storage, most
+// urgent first by the rescue priority. With force, orders are discarded from the shelf to
+// make room, and orders that still have nowhere to go are discarded; since the orders
+// must leave, the move limits do not apply. Pinned orders are left in place for an
+// operator to deal with.
+func (fs *FulfillmentSystem) evacuate(storage *entity.Storage, group *entity.StorageGroup, force bool) {
+	var candidates []*entity.StoredOrder
+	for _, so := range storage.ListOrders() {
+		if so.Pinned {
+			log.Printf("Order %s is pinned, leaving it in %s", so.Order.ID, storage.Name)
+			continue
+		}
+		candidates = append(candidates, so)
+	}
+	for len(candidates) > 0 {
+		so, _ := fs.rescue(candidates, fs.clock.Now())
+		for i, c := range candidates {
+			if c == so {
+				candidates = append(candidates[:i], candidates[i+1:]...)
+				break
+			}
+		}
+		route := fs.route(so.Order.Temperature)
+		destinations := []*entity.StorageGroup{group}
+		for _, g := range route {
+			if g != group {
+				destinations = append(destinations, g)
+			}
+		}
+		if fs.moveOut(so, storage, !force, destinations...) {
+			continue
+		}
+		if !force {
+			continue
+		}
+		fallback := fs.fallback(so.Order.Temperature)
+		if fs.makeRoom(fallback, so.Order) && fs.moveOut(so, storage, false, fallback) {
+			continue
+		}
+		// Nowhere to go, or expired: the order leaves with the unit
+		storage.Remove(so.Order.ID)
+		fs.logAction(so, config.ACTION_TYPE_DISCARD, "", RuleEvacuate, nil)
+		fs.releaseReservations(so.Order.ID)
+	}
+}
+
+// moveOut moves an order from a storage to the first destination group with room for it,
+// within the move limits if limited.
+func (fs *FulfillmentSystem) moveOut(so *entity.StoredOrder, source *entity.Storage, limited bool, destinations ...*entity.StorageGroup) bool {
+	for _, dest := range destinations {
+		if dest != nil && (!limited || fs.mayMove(so, dest, 0)) && fs.atomicMoveOrder(so.Order.ID, source, dest) {
+			fs.logAction(so, config.ACTION_TYPE_MOVE, source.Name, RuleEvacuate, nil)
+			return true
+		}
+	}
+	return false
+}
+
+// drainDegraded moves orders out of degraded units where room has appeared.
+func (fs *FulfillmentSystem) drainDegraded() {
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			if s.Status == entity.StatusDegraded && len(s.Orders) > 0 {
+				fs.evacuate(s, g.group, false)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"fmt"
+)
+
+// Operator overrides let kitchen staff overrule the automatic placement. Each one is
+// recorded in the action log with the operator's name; pins and unpins are annotations
+// that are never submitted to the challenge server.
+
+// DefaultOperator is recorded for overrides that do not name an operator.
+const DefaultOperator = "admin"
+
+// PinOrder pins a stored order: it stays where it is until picked up, moved or discarded
+// by an operator, and is never moved or discarded automatically.
+func (fs *FulfillmentSystem) PinOrder(orderID, operator string) error {
+	return fs.override(func() error { return fs.setPinned(orderID, true, operator) })
+}
+
+// UnpinOrder hands a pinned order back to the automatic placement.
+func (fs *FulfillmentSystem) UnpinOrder(orderID, operator string) error {
+	return fs.override(func() error { return fs.setPinned(orderID, false, operator) })
+}
+
+func (fs *FulfillmentSystem) setPinned(orderID string, pinned bool, operator string) error {
+	so, _, _ := fs.findOrder(orderID)
+	if so == nil {
+		return fmt.Errorf("order %s is not stored", orderID)
+	}
+	if so.Pinned == pinned {
+		return nil
+	}
+	so.Pinned = pinned
+	actionType := config.ACTION_TYPE_PIN
+	if !pinned {
+		actionType = config.ACTION_TYPE_UNPIN
+	}
+	fs.logOverride(so, actionType, "", operator, "")
+	return nil
+}
+
+// MoveOrder moves a stored order to the named storage unit, which must be online and have
+// room for it within its quotas. Routes, reservations and move limits do not apply.
+func (fs *FulfillmentSystem) MoveOrder(orderID, unit, operator, reason string) error {
+	return fs.override(func() error {
+		so, source, group := fs.findOrder(orderID)
+		if so == nil {
+			return fmt.Errorf("order %s is not stored", orderID)
+		}
+		dest, destGroup := fs.findUnit(unit)
+		switch {
+		case dest == nil:
+			return fmt.Errorf("unknown storage unit %q", unit)
+		case dest == source:
+			return fmt.Errorf("order %s is already in %s", orderID, unit)
+		case !dest.Fits(so.Order):
+			return fmt.Errorf("%s has no room for order %s", unit, orderID)
+		}
+		now := fs.clock.Now()
+		source.Remove(orderID)
+		so.Rebase(now)
+		so.Moves++
+		so.MovedAt = now
+		// An operator's move ignores reservations, so it uses up the slots it could take
+		// as a placement.
+		destGroup.Store(dest, so, true)
+		fs.logOverride(so, config.ACTION_TYPE_MOVE, source.Name, operator, reason)
+		fs.refill(group)
+		return nil
+	})
+}
+
+// DiscardOrder discards a stored order for the given reason.
+func (fs *FulfillmentSystem) DiscardOrder(orderID, operator, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a manual discard needs a reason")
+	}
+	return fs.override(func() error {
+		so, source, group := fs.findOrder(orderID)
+		if so == nil {
+			return fmt.Errorf("order %s is not stored", orderID)
+		}
+		source.Remove(orderID)
+		fs.logOverride(so, config.ACTION_TYPE_DISCARD, "", operator, reason)
+		fs.releaseReservations(orderID)
+		fs.refill(group)
+		return nil
+	})
+}
+
+// override runs an operator override on the event loop.
+func (fs *FulfillmentSystem) override(f func() error) error {
+	var err error
+	if !fs.do(func() { err = f() }) {
+		return fmt.Errorf("fulfillment system is closed")
+	}
+	return err
+}
+
+// logOverride records an action ordered by an operator. from names the storage a moved
+// order left.
+func (fs *FulfillmentSystem) logOverride(so *entity.StoredOrder, actionType, from, operator, reason string) {
+	if operator == "" {
+		operator = DefaultOperator
+	}
+	a := fs.newAction(so, actionType, from, RuleOverride, nil)
+	a.Operator, a.Reason = operator, reason
+	fs.appendAction(a)
+}
+
+// findOrder returns a stored order with the unit and group holding it, or nils.
+func (fs *FulfillmentSystem) findOrder(orderID string) (*entity.StoredOrder, *entity.Storage, *entity.StorageGroup) {
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			if so, ok := s.Orders[orderID]; ok {
+				return so, s, g.group
+			}
+		}
+	}
+	return nil, nil, nil
+}
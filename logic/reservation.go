@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"challenge/entity"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Reserve holds back slots of a storage group (cooler, heater or shelf) for upcoming
+// orders and returns the reservation's ID. With an OrderID, only that order may use the
+// slots, and the reservation ends once it is stored in the group or leaves the system;
+// otherwise any order of the reservation's temperature (any order if blank) being placed
+// may use them, and the reservation ends once such orders have taken all its slots.
+// Orders moving into the group, such as shelf orders being rescued, never take reserved
+// slots. The reservation holds from From (at once if zero) until Until (forever if
+// zero), and is dropped automatically when it expires.
+func (fs *FulfillmentSystem) Reserve(group string, r entity.Reservation) (string, error) {
+	var id string
+	var err error
+	if !fs.do(func() { id, err = fs.reserve(group, r) }) {
+		return "", fmt.Errorf("fulfillment system is closed")
+	}
+	return id, err
+}
+
+func (fs *FulfillmentSystem) reserve(group string, r entity.Reservation) (string, error) {
+	g := fs.groupNamed(group)
+	if g == nil {
+		return "", fmt.Errorf("unknown storage group %q", group)
+	}
+	if r.Slots <= 0 {
+		return "", fmt.Errorf("a reservation needs a positive number of slots")
+	}
+	if !r.From.IsZero() && !r.Until.IsZero() && !r.From.Before(r.Until) {
+		return "", fmt.Errorf("reservation window ends before it starts")
+	}
+	fs.reservationSeq++
+	r.ID = fmt.Sprintf("R%d", fs.reservationSeq)
+	g.Reserve(r)
+	g.Expire(fs.clock.Now())
+	log.Printf("Reserved %d %s slots as %s", r.Slots, group, r.ID)
+	return r.ID, nil
+}
+
+// CancelReservation drops a reservation before it expires.
+func (fs *FulfillmentSystem) CancelReservation(id string) error {
+	var err error
+	if !fs.do(func() {
+		for _, g := range fs.groups() {
+			if g.group.Unreserve(id) {
+				fs.refill(g.group)
+				return
+			}
+		}
+		err = fmt.Errorf("unknown reservation %q", id)
+	}) {
+		return fmt.Errorf("fulfillment system is closed")
+	}
+	return err
+}
+
+// expireReservations drops expired reservations and activates those whose window has
+// started. Groups that got slots back are refilled. The event loop runs it before every
+// command.
+func (fs *FulfillmentSystem) expireReservations() {
+	now := fs.clock.Now()
+	for _, g := range fs.groups() {
+		expired := g.group.Expire(now)
+		for _, r := range expired {
+			log.Printf("Reservation %s of %d %s slots expired", r.ID, r.Slots, g.name)
+		}
+		if len(expired) > 0 {
+			fs.refill(g.group)
+		}
+	}
+}
+
+// releaseReservations drops the reservations held for an order that left the system.
+func (fs *FulfillmentSystem) releaseReservations(orderID string) {
+	for _, g := range fs.groups() {
+		g.group.Release(orderID)
+	}
+}
+
+// ReservationSnapshot is the state of a reservation.
+type ReservationSnapshot struct {
+	ID          string     `json:"id"`
+	Group       string     `json:"group"`
+	Slots       int        `json:"slots"`
+	OrderID     string     `json:"order_id,omitempty"`
+	Temperature string     `json:"temperature,omitempty"`
+	From        *time.Time `json:"from,omitempty"`
+	Until       *time.Time `json:"until,omitempty"`
+	Active      bool       `json:"active"` // Whether the slots are being held.
+}
+
+func (fs *FulfillmentSystem) reservationSnapshots() []ReservationSnapshot {
+	var snaps []ReservationSnapshot
+	for _, g := range fs.groups() {
+		for _, r := range g.group.Reservations() {
+			rs := ReservationSnapshot{ID: r.ID, Group: g.name, Slots: r.Slots, OrderID: r.OrderID, Temperature: r.Temperature, Active: r.Active}
+			if !r.From.IsZero() {
+				from := r.From
+				rs.From = &from
+			}
+			if !r.Until.IsZero() {
+				until := r.Until
+				rs.Until = &until
+			}
+			snaps = append(snaps, rs)
+		}
+	}
+	return snaps
+}
@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"log"
+)
+
+// buildRoutes resolves the compatibility matrix into the groups orders of each temperature
+// may be stored in, in order of preference, and applies its decay multipliers to the
+// units. Temperatures without a usable route keep the built-in one. The discard group
+// defaults to the shelf.
+func (fs *FulfillmentSystem) buildRoutes(matrix map[string][]config.RouteConfig, discardGroup string) {
+	fs.discardGroup = fs.ShelfGroup
+	if discardGroup != "" {
+		if group := fs.groupNamed(discardGroup); group != nil {
+			fs.discardGroup = group
+		} else {
+			log.Printf("Unknown discard group %q, using %q", discardGroup, GroupShelf)
+		}
+	}
+	fs.routes = map[string][]*entity.StorageGroup{
+		config.TEMP_TYPE_HOT:  {fs.HeaterGroup, fs.ShelfGroup},
+		config.TEMP_TYPE_COLD: {fs.CoolerGroup, fs.ShelfGroup},
+		config.TEMP_TYPE_ROOM: {fs.ShelfGroup},
+	}
+	for _, temp := range sortedKeys(matrix) {
+		var route []*entity.StorageGroup
+		for _, r := range matrix[temp] {
+			group := fs.groupNamed(r.Group)
+			if group == nil {
+				log.Printf("Unknown storage group %q in the %s route, skipping it", r.Group, temp)
+				continue
+			}
+			if fs.inRoute(route, group) {
+				log.Printf("Storage group %q appears twice in the %s route, skipping it", r.Group, temp)
+				continue
+			}
+			route = append(route, group)
+			if r.DecayMultiplier > 0 {
+				for _, s := range group.Storages {
+					if s.TempDecay == nil {
+						s.TempDecay = make(map[string]float64)
+					}
+					s.TempDecay[temp] = r.DecayMultiplier
+				}
+			}
+		}
+		if len(route) == 0 {
+			log.Printf("Empty %s route, using the built-in one", temp)
+			continue
+		}
+		fs.routes[temp] = route
+	}
+}
+
+// route returns the groups orders of a temperature may be stored in, in order of
+// preference. Unknown temperatures go to the shelf.
+func (fs *FulfillmentSystem) route(temp string) []*entity.StorageGroup {
+	if route, ok := fs.routes[temp]; ok {
+		return route
+	}
+	return []*entity.StorageGroup{fs.ShelfGroup}
+}
+
+// fallback returns the group where room is made by discarding for orders of a
+// temperature: the discard group if their route includes it, else the last group of
+// their route. Orders are only discarded to make room in their own fallback group.
+func (fs *FulfillmentSystem) fallback(temp string) *entity.StorageGroup {
+	route := fs.route(temp)
+	if fs.inRoute(route, fs.discardGroup) {
+		return fs.discardGroup
+	}
+	return route[len(route)-1]
+}
+
+// Accepts returns the known temperatures, sorted, whose routes include the named group.
+// Routes are fixed when the system is created, so it is safe to call at any time.
+func (fs *FulfillmentSystem) Accepts(group string) []string {
+	g := fs.groupNamed(group)
+	var temps []string
+	for _, temp := range sortedKeys(fs.routes) {
+		if g != nil && fs.inRoute(fs.routes[temp], g) {
+			temps = append(temps, temp)
+		}
+	}
+	return temps
+}
+
+// rank returns the position of a group in the route of a temperature, or -1 if orders of
+// that temperature may not be stored there.
+func (fs *FulfillmentSystem) rank(temp string, group *entity.StorageGroup) int {
+	for i, g := range fs.route(temp) {
+		if g == group {
+			return i
+		}
+	}
+	return -1
+}
+
+// prefers reports whether orders of a temperature would rather be in group a than in b.
+func (fs *FulfillmentSystem) prefers(temp string, a, b *entity.StorageGroup) bool {
+	ra, rb := fs.rank(temp, a), fs.rank(temp, b)
+	return ra >= 0 && (rb < 0 || ra < rb)
+}
+
+func (fs *FulfillmentSystem) inRoute(route []*entity.StorageGroup, group *entity.StorageGroup) bool {
+	for _, g := range route {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// groupNamed returns the group with the given snapshot name, or nil.
+func (fs *FulfillmentSystem) groupNamed(name string) *entity.StorageGroup {
+	for _, g := range fs.groups() {
+		if g.name == name {
+			return g.group
+		}
+	}
+	return nil
+}
+
+// groupName returns the snapshot name of a group.
+func (fs *FulfillmentSystem) groupName(group *entity.StorageGroup) string {
+	for _, g := range fs.groups() {
+		if g.group == group {
+			return g.name
+		}
+	}
+	return ""
+}
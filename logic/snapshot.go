@@ -0,0 +1,216 @@
+package logic
+
+import (
+	"challenge/entity"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Snapshot is an immutable, point-in-time copy of every storage of a FulfillmentSystem.
+type Snapshot struct {
+	Taken        time.Time             `json:"taken"`
+	Storages     []StorageSnapshot     `json:"storages"`
+	Reservations []ReservationSnapshot `json:"reservations,omitempty"`
+}
+
+// StorageSnapshot is the state of a single storage unit.
+type StorageSnapshot struct {
+	Name     string          `json:"name"`
+	Group    string          `json:"group"`
+	Capacity int             `json:"capacity"`
+	Used     int             `json:"used"`                    // Slots taken by the orders.
+	Decay    float64         `json:"decay_multiplier"`        // Freshness decay rate relative to normal, including conditions.
+	Status   string          `json:"status"`                  // Online, degraded or offline.
+	TempC    *float64        `json:"temperature_c,omitempty"` // Sensor reading, if the unit has a sensor.
+	Quotas   []QuotaSnapshot `json:"quotas,omitempty"`        // Per-temperature limits and their usage.
+	Orders   []OrderSnapshot `json:"orders"`
+}
+
+// QuotaSnapshot is the usage of a storage's quota for one temperature.
+type QuotaSnapshot struct {
+	Temperature string `json:"temperature"`
+	Max         int    `json:"max,omitempty"`
+	Reserved    int    `json:"reserved,omitempty"`
+	Used        int    `json:"used"` // Slots taken by orders of the temperature.
+}
+
+// OrderSnapshot is the state of a single stored order.
+type OrderSnapshot struct {
+	ID                 string        `json:"id"`
+	Name               string        `json:"name"`
+	Temperature        string        `json:"temperature"`
+	Size               int           `json:"size"` // Slots the order takes up.
+	PlacedAt           time.Time     `json:"placed_at"`
+	RemainingFreshness time.Duration `json:"remaining_freshness"`
+	Pinned             bool          `json:"pinned,omitempty"`
+	Moves              int           `json:"moves,omitempty"` // Times the order was moved.
+}
+
+// Storage group names used in snapshots.
+const (
+	GroupCooler = "cooler"
+	GroupHeater = "heater"
+	GroupShelf  = "shelf"
+)
+
+// Snapshot copies the state of every storage group atomically: it runs on the event loop,
+// so no operation is applied halfway through the copy.
+func (fs *FulfillmentSystem) Snapshot() Snapshot {
+	var snap Snapshot
+	if !fs.do(func() { snap = fs.snapshot() }) {
+		snap = fs.snapshot()
+	}
+	return snap
+}
+
+// snapshot copies the state without going through the event loop.
+func (fs *FulfillmentSystem) snapshot() Snapshot {
+	now := fs.clock.Now()
+	snap := Snapshot{Taken: now, Reservations: fs.reservationSnapshots()}
+	for _, g := range fs.groups() {
+		for _, s := range g.group.Storages {
+			ss := StorageSnapshot{
+				Name:     s.Name,
+				Group:    g.name,
+				Capacity: s.Capacity,
+				Used:     s.Used(),
+				Decay:    s.DecayRate(),
+				Status:   s.Status,
+				Orders:   []OrderSnapshot{},
+			}
+			if ss.Status == "" {
+				ss.Status = entity.StatusOnline
+			}
+			for _, temp := range sortedKeys(s.Quotas) {
+				q := s.Quotas[temp]
+				ss.Quotas = append(ss.Quotas, QuotaSnapshot{Temperature: temp, Max: q.Max, Reserved: q.Reserved, Used: s.UsedBy(temp)})
+			}
+			if temp, ok := fs.temperatureAt(s.Name, now); ok {
+				ss.TempC = &temp
+			}
+			for _, so := range s.Orders {
+				ss.Orders = append(ss.Orders, newOrderSnapshot(so, now))
+			}
+			sort.Slice(ss.Orders, func(i, j int) bool {
+				if !ss.Orders[i].PlacedAt.Equal(ss.Orders[j].PlacedAt) {
+					return ss.Orders[i].PlacedAt.Before(ss.Orders[j].PlacedAt)
+				}
+				return ss.Orders[i].ID < ss.Orders[j].ID
+			})
+			snap.Storages = append(snap.Storages, ss)
+		}
+	}
+	return snap
+}
+
+func newOrderSnapshot(so *entity.StoredOrder, now time.Time) OrderSnapshot {
+	return OrderSnapshot{
+		ID:                 so.Order.ID,
+		Name:               so.Order.Name,
+		Temperature:        so.Order.Temperature,
+		Size:               so.Order.Units(),
+		PlacedAt:           so.PlacedAt,
+		RemainingFreshness: so.RemainingFreshnessAt(now),
+		Pinned:             so.Pinned,
+		Moves:              so.Moves,
+	}
+}
+
+// namedGroup pairs a storage group with its snapshot name.
+type namedGroup struct {
+	name  string
+	group *entity.StorageGroup
+}
+
+// groups lists the storage groups in a fixed order.
+func (fs *FulfillmentSystem) groups() []namedGroup {
+	return []namedGroup{
+		{GroupCooler, fs.CoolerGroup},
+		{GroupHeater, fs.HeaterGroup},
+		{GroupShelf, fs.ShelfGroup},
+	}
+}
+
+// Storage returns the snapshot of the named storage unit.
+func (s Snapshot) Storage(name string) (StorageSnapshot, bool) {
+	for _, ss := range s.Storages {
+		if ss.Name == name {
+			return ss, true
+		}
+	}
+	return StorageSnapshot{}, false
+}
+
+// Find returns the order with the given ID and the name of the storage holding it.
+func (s Snapshot) Find(orderID string) (OrderSnapshot, string, bool) {
+	for _, ss := range s.Storages {
+		for _, o := range ss.Orders {
+			if o.ID == orderID {
+				return o, ss.Name, true
+			}
+		}
+	}
+	return OrderSnapshot{}, "", false
+}
+
+// Group returns the snapshots of all storages of a group.
+func (s Snapshot) Group(group string) []StorageSnapshot {
+	var storages []StorageSnapshot
+	for _, ss := range s.Storages {
+		if ss.Group == group {
+			storages = append(storages, ss)
+		}
+	}
+	return storages
+}
+
+// JSON serializes the snapshot as indented JSON, with freshness in seconds.
+func (s Snapshot) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// orderSnapshotJSON is the wire form of OrderSnapshot, with freshness in seconds.
+type orderSnapshotJSON struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Temperature        string    `json:"temperature"`
+	Size               int       `json:"size"`
+	PlacedAt           time.Time `json:"placed_at"`
+	RemainingFreshness float64   `json:"remaining_freshness"`
+	Pinned             bool      `json:"pinned,omitempty"`
+	Moves              int       `json:"moves,omitempty"`
+}
+
+// MarshalJSON writes the remaining freshness in seconds rather than nanoseconds.
+func (o OrderSnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(orderSnapshotJSON{
+		ID:                 o.ID,
+		Name:               o.Name,
+		Temperature:        o.Temperature,
+		Size:               o.Size,
+		PlacedAt:           o.PlacedAt,
+		RemainingFreshness: o.RemainingFreshness.Seconds(),
+		Pinned:             o.Pinned,
+		Moves:              o.Moves,
+	})
+}
+
+// UnmarshalJSON reads the form written by MarshalJSON.
+func (o *OrderSnapshot) UnmarshalJSON(data []byte) error {
+	var w orderSnapshotJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*o = OrderSnapshot{
+		ID:                 w.ID,
+		Name:               w.Name,
+		Temperature:        w.Temperature,
+		Size:               w.Size,
+		PlacedAt:           w.PlacedAt,
+		RemainingFreshness: time.Duration(w.RemainingFreshness * float64(time.Second)),
+		Pinned:             w.Pinned,
+		Moves:              w.Moves,
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"challenge/entity"
+	"sort"
+	"time"
+)
+
+// Placement policies decide how hard the system tries to make room on a full shelf
+// before it resorts to discarding.
+const (
+	// PlacementIdealFirst places orders in their ideal storage, then on the shelf, and
+	// only tries to move the discard candidate's temperature off a full shelf.
+	PlacementIdealFirst = "ideal-first"
+	// PlacementRescueAny behaves like PlacementIdealFirst but moves any hot or cold shelf
+	// order with room in its ideal storage before discarding.
+	PlacementRescueAny = "rescue-any"
+)
+
+// Discard policy names.
+const (
+	DiscardLeastFresh    = "least-fresh"    // Lowest remaining freshness.
+	DiscardLeastFraction = "least-fraction" // Lowest remaining freshness relative to its shelf life.
+	DiscardOldest        = "oldest"         // Placed earliest.
+)
+
+// DiscardPolicy picks the order to discard among the candidates.
+type DiscardPolicy func(candidates []*entity.StoredOrder, now time.Time) (*entity.StoredOrder, bool)
+
+var discardPolicies = map[string]DiscardPolicy{
+	DiscardLeastFresh: minBy(func(so *entity.StoredOrder, now time.Time) float64 {
+		return float64(so.RemainingFreshnessAt(now))
+	}),
+	DiscardLeastFraction: minBy(func(so *entity.StoredOrder, now time.Time) float64 {
+		life := so.Order.ShelfLife()
+		if life <= 0 {
+			return 0
+		}
+		return float64(so.RemainingFreshnessAt(now)) / float64(life)
+	}),
+	DiscardOldest: minBy(func(so *entity.StoredOrder, now time.Time) float64 {
+		return float64(so.PlacedAt.UnixNano())
+	}),
+}
+
+// Rescue priority names, ordering the shelf orders moved back to ideal storage.
+const (
+	RescueMostUrgent    = "most-urgent"    // Lowest remaining freshness first.
+	RescueLeastFraction = "least-fraction" // Lowest remaining freshness relative to its shelf life first.
+	RescueOldest        = "oldest"         // Placed earliest first.
+)
+
+// RescuePriority picks the shelf order to move back to ideal storage first among the candidates.
+type RescuePriority func(candidates []*entity.StoredOrder, now time.Time) (*entity.StoredOrder, bool)
+
+var rescuePriorities = map[string]RescuePriority{
+	RescueMostUrgent:    RescuePriority(discardPolicies[DiscardLeastFresh]),
+	RescueLeastFraction: RescuePriority(discardPolicies[DiscardLeastFraction]),
+	RescueOldest:        RescuePriority(discardPolicies[DiscardOldest]),
+}
+
+var placementPolicies = map[string]bool{
+	PlacementIdealFirst: true,
+	PlacementRescueAny:  true,
+}
+
+// LookupDiscardPolicy returns the discard policy with the given name.
+func LookupDiscardPolicy(name string) (DiscardPolicy, bool) {
+	p, ok := discardPolicies[name]
+	return p, ok
+}
+
+// LookupRescuePriority returns the rescue priority with the given name.
+func LookupRescuePriority(name string) (RescuePriority, bool) {
+	p, ok := rescuePriorities[name]
+	return p, ok
+}
+
+// RescuePriorityNames lists the registered rescue priorities.
+func RescuePriorityNames() []string {
+	return sortedKeys(rescuePriorities)
+}
+
+// DiscardPolicyNames lists the registered discard policies.
+func DiscardPolicyNames() []string {
+	return sortedKeys(discardPolicies)
+}
+
+// PlacementPolicyNames lists the registered placement policies.
+func PlacementPolicyNames() []string {
+	return sortedKeys(placementPolicies)
+}
+
+// minBy builds a discard policy choosing the candidate with the lowest key.
+// Ties are broken by order ID so the choice does not depend on map iteration order.
+func minBy(key func(so *entity.StoredOrder, now time.Time) float64) DiscardPolicy {
+	return func(candidates []*entity.StoredOrder, now time.Time) (*entity.StoredOrder, bool) {
+		var best *entity.StoredOrder
+		var bestKey float64
+		for _, so := range candidates {
+			k := key(so, now)
+			if best == nil || k < bestKey || (k == bestKey && so.Order.ID < best.Order.ID) {
+				best, bestKey = so, k
+			}
+		}
+		return best, best != nil
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"challenge/entity"
+	"sort"
+	"time"
+)
+
+// Rules explaining why an action happened, recorded as its rule.
+const (
+	RuleIdeal        = "ideal"                    // Placed in the first storage of its route.
+	RuleIdealFull    = "ideal-full"               // Placed further down its route, the storage it prefers being full.
+	RuleFallbackFull = "fallback-full"            // Room made in the route's fallback (the shelf by default) by discarding.
+	RuleMovedInstead = "moved-instead-of-discard" // Moved to a storage its route prefers to make room, sparing a discard.
+	RuleRefill       = "refill"                   // Moved into a storage its route prefers when room appeared there.
+	RuleEvacuate     = "evacuate"                 // Moved out of, or discarded with, a unit taken offline or degraded.
+	RulePickup       = "pickup"                   // Picked up by a courier.
+	RuleCancelled    = "cancelled"                // Discarded because the order was cancelled.
+	RuleRejected     = "rejected"                 // Discarded on arrival, no room being possible for it.
+	RuleOverride     = "override"                 // Ordered by an operator, who gives the reason.
+)
+
+// Decision lists the orders considered for an action that had to choose among them,
+// including the one acted on.
+type Decision struct {
+	Candidates []Candidate // Orders considered, most urgent first.
+}
+
+// Candidate is an order considered for an action, as it was at the time.
+type Candidate struct {
+	OrderID   string        // Order identifier.
+	Storage   string        // Storage unit holding the order.
+	Freshness time.Duration // Remaining freshness of the order.
+}
+
+// decide builds the decision for an action choosing among candidates when decisions are
+// traced, or returns nil.
+func (fs *FulfillmentSystem) decide(candidates []*entity.StoredOrder) *Decision {
+	if !fs.traceDecisions || len(candidates) == 0 {
+		return nil
+	}
+	now := fs.clock.Now()
+	d := &Decision{}
+	for _, so := range candidates {
+		d.Candidates = append(d.Candidates, Candidate{OrderID: so.Order.ID, Storage: so.Storage, Freshness: so.RemainingFreshnessAt(now)})
+	}
+	sort.Slice(d.Candidates, func(i, j int) bool {
+		a, b := d.Candidates[i], d.Candidates[j]
+		if a.Freshness != b.Freshness {
+			return a.Freshness < b.Freshness
+		}
+		return a.OrderID < b.OrderID
+	})
+	return d
+}
+
+// Trace returns the actions on an order, in the order they happened, with the candidates
+// considered for them when decisions are traced.
+func (fs *FulfillmentSystem) Trace(orderID string) []Action {
+	var trace []Action
+	for _, a := range fs.ActionLog() {
+		if a.OrderID == orderID {
+			trace = append(trace, a)
+		}
+	}
+	return trace
+}
@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"os"
 	"time"
 
+	"challenge/actionlog"
+	"challenge/arrival"
+	"challenge/catalog"
 	css "challenge/client"
 	"challenge/config"
 	"challenge/entity"
 	"challenge/logic"
+	"challenge/report"
+	"challenge/sim"
+	"challenge/workload"
 )
 
 var (
@@ -25,10 +32,41 @@ var (
 	min  = flag.Duration("min", 4*time.Second, "Minimum pickup time")
 	max  = flag.Duration("max", 8*time.Second, "Maximum pickup time")
 
+	// Arrival model, overriding the config file.
+	arrivalSpec = flag.String("arrival", "", "Arrival model, e.g. poisson:rate=2 (config file or constant --rate if blank)")
+
+	// Harness concurrency.
+	workers    = flag.Int("workers", logic.DefaultHarnessWorkers, "Number of harness workers running place and pickup operations")
+	maxPending = flag.Int("max-pending", 0, "Pause taking new orders while this many await pickup (0 for no limit)")
+
+	// Admin console for storage outages and operator overrides.
+	admin    = flag.Bool("admin", false, "Read admin commands (status, online/degraded/offline <unit>, pin/move/discard <order>) from standard input during the run")
+	operator = flag.String("operator", defaultOperator(), "Operator name recorded with overrides made from the admin console")
+
+	// Menu catalog used to fill in and validate incoming orders.
+	catalogFile = flag.String("catalog", "", "Path to a JSON menu catalog (optional)")
+
 	// Config file for storage configuration
 	configFile = flag.String("config", "config/init.json", "Path to storage configuration file")
+
+	// Action log export.
+	actionsOut    = flag.String("actions-out", "", "Write captured actions to this file (optional)")
+	actionsFormat = flag.String("actions-format", "", "Action log format: jsonl, csv or solution (inferred from --actions-out extension if blank)")
+
+	// Run summary report.
+	reportOut = flag.String("report-out", "", "Write the run summary report as JSON to this file (optional)")
 )
 
+// commands maps subcommand names to their entry points. Without a subcommand the
+// program fetches a problem from the challenge server and solves it.
+var commands = map[string]func(args []string){
+	"bench":  runBench,
+	"gen":    runGen,
+	"plan":   runPlan,
+	"replay": runReplay,
+	"oracle": runOracle,
+}
+
 ///////////////////////////
 // Fulfillment System    //
 ///////////////////////////
@@ -40,6 +78,13 @@ var (
 // main integrates our fulfillment system with the challenge client.
 // It fetches orders from the server, processes them, and submits the actions.
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Parse()
 	rand.Seed(time.Now().UnixNano())
 
@@ -58,33 +103,62 @@ func main() {
 		log.Printf("Received order: %+v", o)
 	}
 
-	// Convert orders from the challenge client's type to our internal Order type.
+	// Load the menu catalog, if any.
+	var menu *catalog.Catalog
+	if *catalogFile != "" {
+		c, err := catalog.Load(*catalogFile)
+		if err != nil {
+			log.Fatalf("Failed to load catalog: %v", err)
+		}
+		menu = &c
+	}
+
+	// Convert orders from the challenge client's type to our internal Order type,
+	// filling in menu defaults and flagging orders that disagree with the menu.
 	var orders []entity.Order
 	for _, o := range ordersFromServer {
-		orders = append(orders, entity.Order{
-			ID:               o.ID,
-			Name:             o.Name,
-			Temperature:      o.Temp,                                   // Assuming client's field is Temp.
-			Freshness:        time.Duration(o.Freshness) * time.Second, // Convert seconds to time.Duration.
-			InitialFreshness: time.Duration(o.Freshness) * time.Second,
-		})
+		if menu == nil {
+			orders = append(orders, workload.ToOrder(o))
+			continue
+		}
+		order, err := workload.ToMenuOrder(o, *menu)
+		if err != nil {
+			log.Printf("Order %s does not match the menu: %v", o.ID, err)
+		}
+		orders = append(orders, order)
 	}
 
+	arrivals, err := arrivalModel(*arrivalSpec, cfg.Arrival, *rate)
+	if err != nil {
+		log.Fatalf("Invalid arrival model: %v", err)
+	}
+	log.Printf("Orders arrive as %v", arrivals)
+
 	// Initialize our fulfillment system with the configuration.
 	fs := logic.NewFulfillmentSystem(cfg)
 
+	if *admin {
+		go fs.AdminConsole(os.Stdin, os.Stderr, *operator)
+	}
+
 	// Run the simulation harness with command-line timing parameters
-	fs.RunHarness(orders, *rate, *min, *max)
+	fs.StreamHarness(logic.Stream(orders), logic.HarnessOptions{
+		OrderInterval: *rate,
+		Arrivals:      arrivals,
+		MinPickup:     *min,
+		MaxPickup:     *max,
+		Workers:       *workers,
+		MaxPending:    *maxPending,
+		Seed:          time.Now().UnixNano(),
+	})
+	// Take the results through the event loop, since an admin command may still be running,
+	// then close it; commands typed afterwards are dropped.
+	actionLog := fs.ActionLog()
+	layout := report.LayoutOf(fs)
+	fs.Close()
 
 	// Convert our internal actions to the challenge client's action format.
-	var actions []css.Action
-	for _, a := range fs.Actions {
-		actions = append(actions, css.Action{
-			Timestamp: a.Timestamp,
-			ID:        a.OrderID,
-			Action:    a.Action,
-		})
-	}
+	actions := actionlog.ToClientActions(actionLog)
 
 	// Submit the solution using command-line timing parameters
 	result, err := client.Solve(id, *rate, *min, *max, actions)
@@ -100,9 +174,79 @@ func main() {
 	}
 	time.Sleep(500 * time.Millisecond)
 
-	// Optionally, print all captured actions.
-	fmt.Println("\nCaptured Actions:")
-	for _, act := range fs.Actions {
-		fmt.Printf("%+v\n", act)
+	// Export the captured actions for offline analysis.
+	if *actionsOut != "" {
+		format := actionlog.FormatFromPath(*actionsOut)
+		if *actionsFormat != "" {
+			if format, err = actionlog.ParseFormat(*actionsFormat); err != nil {
+				log.Fatalf("Invalid --actions-format: %v", err)
+			}
+		}
+		if err := actionlog.ExportFile(*actionsOut, format, actionLog, *rate, *min, *max); err != nil {
+			log.Fatalf("Failed to export actions: %v", err)
+		}
+		log.Printf("Exported %d actions to %s (%s)", len(actionLog), *actionsOut, format)
+	}
+
+	// Summarize the run.
+	summary := report.Summarize(orders, actionLog, layout)
+	fmt.Println("\nRun Summary:")
+	report.Print(os.Stdout, summary)
+	if *reportOut != "" {
+		if err := report.WriteJSON(*reportOut, summary); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+}
+
+// arrivalModel resolves the arrival model from an --arrival flag value, falling back to the
+// configuration file and then to constant arrivals every interval.
+func arrivalModel(spec string, cfg *config.ArrivalConfig, interval time.Duration) (arrival.Model, error) {
+	if spec != "" {
+		parsed, err := arrival.ParseSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		cfg = parsed
+	}
+	return arrival.FromConfig(cfg, interval)
+}
+
+// loadWorkload reads the orders of a simulated run. When every order records its arrival
+// and pickup times, as gen --timestamps writes them, they are returned too, as offsets
+// from the first arrival, for the run to replay instead of drawing its own.
+func loadWorkload(path string) ([]entity.Order, []sim.Stay, error) {
+	generated, err := workload.LoadGenerated(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	orders := make([]entity.Order, 0, len(generated))
+	timed := len(generated) > 0
+	var start int64
+	for i, o := range generated {
+		orders = append(orders, workload.ToOrder(o.Order))
+		timed = timed && o.Timed()
+		if i == 0 || o.ArriveAt < start {
+			start = o.ArriveAt
+		}
+	}
+	if !timed {
+		return orders, nil, nil
+	}
+	stays := make([]sim.Stay, 0, len(generated))
+	for _, o := range generated {
+		stays = append(stays, sim.Stay{
+			Arrive: time.Duration(o.ArriveAt-start) * time.Microsecond,
+			Pickup: time.Duration(o.PickupAt-start) * time.Microsecond,
+		})
+	}
+	return orders, stays, nil
+}
+
+// defaultOperator names the operator after the user running the program.
+func defaultOperator() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
 	}
+	return logic.DefaultOperator
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"challenge/actionlog"
+	"challenge/config"
+	"challenge/logic"
+	"challenge/oracle"
+	"challenge/report"
+	"challenge/sim"
+)
+
+// runOracle implements the oracle command: it solves a scenario with full knowledge of the
+// pickup times and compares the plan with the online policy on the same scenario.
+func runOracle(args []string) {
+	cmd := flag.NewFlagSet("oracle", flag.ExitOnError)
+	ordersPath := cmd.String("orders", "", "Path to a JSON or JSONL file of orders (required)")
+	configPath := cmd.String("config", "config/init.json", "Path to storage configuration file")
+	seed := cmd.Int64("seed", 1, "Seed for the pickup delays")
+	orderRate := cmd.Duration("rate", 500*time.Millisecond, "Inverse order rate (time between order placements)")
+	minPickup := cmd.Duration("min", 4*time.Second, "Minimum pickup time")
+	maxPickup := cmd.Duration("max", 8*time.Second, "Maximum pickup time")
+	arrivalSpec := cmd.String("arrival", "", "Arrival model, e.g. poisson:rate=2 (config file or constant --rate if blank)")
+	objective := cmd.String("objective", oracle.ObjectiveWaste, "Objective: waste or freshness")
+	opts := oracle.DefaultOptions()
+	cmd.IntVar(&opts.ExactLimit, "exact-limit", opts.ExactLimit, "Largest number of orders solved exactly")
+	cmd.IntVar(&opts.Iterations, "iterations", opts.Iterations, "Local search iterations for larger instances")
+	actionsOut := cmd.String("actions-out", "", "Write the oracle's actions to this file (optional)")
+	cmd.Parse(args)
+
+	if *ordersPath == "" {
+		log.Fatalf("oracle: --orders is required")
+	}
+	if *objective != oracle.ObjectiveWaste && *objective != oracle.ObjectiveFreshness {
+		log.Fatalf("oracle: unknown objective %q", *objective)
+	}
+	orders, stays, err := loadWorkload(*ordersPath)
+	if err != nil {
+		log.Fatalf("oracle: %v", err)
+	}
+	cfg, err := config.ReadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("oracle: %v", err)
+	}
+	opts.Seed = *seed
+	arrivals, err := arrivalModel(*arrivalSpec, cfg.Arrival, *orderRate)
+	if err != nil {
+		log.Fatalf("oracle: %v", err)
+	}
+
+	sc := sim.Scenario{Orders: orders, Rate: *orderRate, Arrivals: arrivals, Min: *minPickup, Max: *maxPickup, Seed: *seed, Stays: stays}
+	log.SetOutput(io.Discard)
+	online := sim.Run(cfg, sc)
+	start := time.Now()
+	solution := oracle.Solve(oracle.Problem{Visits: sc.Visits(), Layout: online.Layout, Objective: *objective}, opts)
+	elapsed := time.Since(start)
+	log.SetOutput(os.Stderr)
+
+	offline := report.Summarize(sc.Orders, solution.Actions, online.Layout)
+	violations := report.Validate(sc.Orders, solution.Actions, online.Layout)
+	for _, v := range violations {
+		log.Printf("Oracle plan violation: %v", v)
+	}
+
+	method := "heuristic"
+	if solution.Exact {
+		method = "exact"
+	}
+	fmt.Printf("Oracle solved %d orders in %v (%s)\n\n", len(sc.Orders), elapsed.Round(time.Millisecond), method)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POLICY\tDISCARDED\tDISCARD RATE\tMOVES\tFRESHNESS\tVIOLATIONS")
+	for _, row := range []struct {
+		name       string
+		summary    report.Summary
+		violations int
+	}{
+		{"online (" + policyName(cfg) + ")", online.Summary, len(online.Violations)},
+		{"oracle", offline, len(violations)},
+	} {
+		s := row.summary
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%d\t%.3f\t%d\n", row.name, s.Actions[config.ACTION_TYPE_DISCARD], 100*s.DiscardRate(),
+			s.Actions[config.ACTION_TYPE_MOVE], s.Freshness.Mean, row.violations)
+	}
+	tw.Flush()
+
+	if *actionsOut != "" {
+		if err := actionlog.ExportFile(*actionsOut, actionlog.FormatFromPath(*actionsOut), solution.Actions, *orderRate, *minPickup, *maxPickup); err != nil {
+			log.Fatalf("oracle: %v", err)
+		}
+	}
+}
+
+// policyName describes the placement and discard policies a configuration selects.
+func policyName(cfg config.FulfillmentConfig) string {
+	placement, discard := cfg.PlacementPolicy, cfg.DiscardPolicy
+	if placement == "" {
+		placement = logic.PlacementIdealFirst
+	}
+	if discard == "" {
+		discard = logic.DiscardLeastFresh
+	}
+	return placement + "/" + discard
+}
@@ -0,0 +1,154 @@
+package oracle
+
+import (
+	"challenge/config"
+	"challenge/logic"
+	"challenge/report"
+	"challenge/sim"
+	"sort"
+	"time"
+)
+
+// Objectives the solver can optimize.
+const (
+	// ObjectiveWaste minimizes the number of discarded orders, preferring fresher pickups on ties.
+	ObjectiveWaste = "waste"
+	// ObjectiveFreshness maximizes the total freshness fraction delivered at pickup.
+	ObjectiveFreshness = "freshness"
+)
+
+// Problem is a clairvoyant instance: every order's placement and pickup time is known upfront.
+// The solver treats every order as taking a single slot.
+type Problem struct {
+	Visits    []sim.Visit
+	Layout    []report.StorageInfo
+	Objective string
+}
+
+// Options tunes the solver.
+type Options struct {
+	ExactLimit int   // Largest number of orders solved exactly; larger instances use the heuristic.
+	NodeLimit  int   // Search nodes after which the exact solver gives up and keeps its best solution.
+	Iterations int   // Local search iterations of the heuristic.
+	Seed       int64 // Seed for the local search.
+}
+
+// DefaultOptions returns options suitable for instances of a few hundred orders.
+func DefaultOptions() Options {
+	return Options{ExactLimit: 16, NodeLimit: 2000000, Iterations: 300, Seed: 1}
+}
+
+// Solution is the solver's plan, rendered as an action log.
+type Solution struct {
+	Actions   []logic.Action
+	Discarded int     // Orders discarded, including those that could not be delivered fresh.
+	Freshness float64 // Total freshness fraction delivered at pickup.
+	Exact     bool    // Whether the plan is proven optimal.
+}
+
+// The solver works on an aggregated model matching the fulfillment system's accounting:
+// an order loses freshness at the same rate wherever it is stored, and moves are free and
+// instantaneous, so only which orders get discarded matters. Ideal storage is always filled
+// before the shelf, and a group of storages holding hot, cold and room orders has room as
+// long as the overflow of the ideal groups plus the room orders fits on the shelf.
+
+// event is a placement or pickup in time order; pickups sort first at the same instant.
+type event struct {
+	at     time.Time
+	pickup bool
+	job    int
+}
+
+// model is the preprocessed problem shared by the exact and heuristic solvers.
+type model struct {
+	problem  Problem
+	events   []event
+	temps    []string
+	values   []float64 // Objective value of delivering each order.
+	doomed   []bool    // Orders that would expire before pickup, discarded right after placement.
+	coolers  int       // Total cooler slots.
+	heaters  int       // Total heater slots.
+	shelves  int       // Total shelf slots.
+	upcoming []float64 // Sum of values of orders placed at or after each event.
+}
+
+func newModel(p Problem) *model {
+	m := &model{problem: p}
+	for i, v := range p.Visits {
+		m.events = append(m.events, event{at: v.PlaceAt, job: i}, event{at: v.PickupAt, pickup: true, job: i})
+		m.temps = append(m.temps, v.Order.Temperature)
+		life := v.Order.ShelfLife()
+		remaining := life - v.PickupAt.Sub(v.PlaceAt)
+		fraction := 0.0
+		if life > 0 && remaining > 0 {
+			fraction = float64(remaining) / float64(life)
+		}
+		m.doomed = append(m.doomed, remaining <= 0)
+		value := fraction
+		if p.Objective != ObjectiveFreshness && remaining > 0 {
+			value = 1 + fraction/1000
+		}
+		m.values = append(m.values, value)
+	}
+	sort.SliceStable(m.events, func(i, j int) bool {
+		if !m.events[i].at.Equal(m.events[j].at) {
+			return m.events[i].at.Before(m.events[j].at)
+		}
+		return m.events[i].pickup && !m.events[j].pickup
+	})
+	m.upcoming = make([]float64, len(m.events)+1)
+	for i := len(m.events) - 1; i >= 0; i-- {
+		m.upcoming[i] = m.upcoming[i+1]
+		if !m.events[i].pickup {
+			m.upcoming[i] += m.values[m.events[i].job]
+		}
+	}
+	for _, s := range p.Layout {
+		switch s.Group {
+		case report.GroupCooler:
+			m.coolers += s.Capacity
+		case report.GroupHeater:
+			m.heaters += s.Capacity
+		case report.GroupShelf:
+			m.shelves += s.Capacity
+		}
+	}
+	return m
+}
+
+// counts tracks how many hot, cold and room orders are stored.
+type counts struct {
+	hot, cold, room int
+}
+
+func (c *counts) add(temp string, delta int) {
+	switch temp {
+	case config.TEMP_TYPE_HOT:
+		c.hot += delta
+	case config.TEMP_TYPE_COLD:
+		c.cold += delta
+	default:
+		c.room += delta
+	}
+}
+
+func (m *model) fits(c counts) bool {
+	return max(0, c.hot-m.heaters)+max(0, c.cold-m.coolers)+c.room <= m.shelves
+}
+
+// Solve computes a plan, exactly for small instances and heuristically otherwise.
+func Solve(p Problem, opts Options) Solution {
+	m := newModel(p)
+	evictions, value := m.heuristic(opts)
+	exact := false
+	if len(p.Visits) <= opts.ExactLimit {
+		if better, v, proven := m.exact(evictions, value, opts.NodeLimit); proven {
+			evictions, exact = better, true
+		} else if v > value {
+			evictions = better
+		}
+	}
+	sol := m.render(evictions)
+	sol.Exact = exact
+	return sol
+}
@@ -0,0 +1,157 @@
+package oracle
+
+import (
+	"challenge/config"
+	"challenge/logic"
+	"challenge/report"
+	"time"
+)
+
+// unit is a concrete storage unit while rendering a plan.
+type unit struct {
+	info   report.StorageInfo
+	orders map[int]bool
+}
+
+func (u *unit) full() bool {
+	return len(u.orders) >= u.info.Capacity
+}
+
+// renderer turns eviction decisions into a concrete action log, assigning orders to
+// storage units first-fit and refilling ideal storage from the shelf whenever it frees up.
+type renderer struct {
+	m        *model
+	units    map[string][]*unit // Storage group to its units.
+	location map[int]*unit
+	actions  []logic.Action
+	sol      Solution
+}
+
+func (m *model) render(evictions map[int]int) Solution {
+	r := &renderer{m: m, units: make(map[string][]*unit), location: make(map[int]*unit)}
+	for _, s := range m.problem.Layout {
+		r.units[s.Group] = append(r.units[s.Group], &unit{info: s, orders: make(map[int]bool)})
+	}
+	for _, ev := range m.events {
+		j := ev.job
+		if ev.pickup {
+			if u, ok := r.location[j]; ok {
+				life := m.problem.Visits[j].Order.ShelfLife()
+				if life > 0 {
+					r.sol.Freshness += float64(r.freshness(j, ev.at)) / float64(life)
+				}
+				r.remove(j, config.ACTION_TYPE_PICKUP, ev.at)
+				r.refill(u, ev.at)
+			}
+			continue
+		}
+		if !m.placeable(j) {
+			continue
+		}
+		if x, ok := evictions[j]; ok {
+			if u, stored := r.location[x]; stored {
+				r.remove(x, config.ACTION_TYPE_DISCARD, ev.at)
+				r.sol.Discarded++
+				r.refill(u, ev.at)
+			}
+		}
+		r.place(j, ev.at)
+		if u, ok := r.location[j]; ok && m.doomed[j] {
+			r.remove(j, config.ACTION_TYPE_DISCARD, ev.at)
+			r.sol.Discarded++
+			r.refill(u, ev.at)
+		}
+	}
+	r.sol.Actions = r.actions
+	return r.sol
+}
+
+// idealGroup returns the storage group an order of the given temperature belongs in.
+func idealGroup(temp string) string {
+	switch temp {
+	case config.TEMP_TYPE_HOT:
+		return report.GroupHeater
+	case config.TEMP_TYPE_COLD:
+		return report.GroupCooler
+	}
+	return report.GroupShelf
+}
+
+func (r *renderer) freshness(j int, at time.Time) time.Duration {
+	v := r.m.problem.Visits[j]
+	return v.Order.ShelfLife() - at.Sub(v.PlaceAt)
+}
+
+func (r *renderer) firstFree(group string) *unit {
+	for _, u := range r.units[group] {
+		if !u.full() {
+			return u
+		}
+	}
+	return nil
+}
+
+func (r *renderer) log(j int, action string, from, u *unit, at time.Time) {
+	order := r.m.problem.Visits[j].Order
+	a := logic.Action{
+		Timestamp:   at.UnixMicro(),
+		OrderID:     order.ID,
+		Action:      action,
+		Temperature: order.Temperature,
+		Storage:     u.info.Name,
+		Freshness:   r.freshness(j, at),
+	}
+	switch action {
+	case config.ACTION_TYPE_PLACE:
+		a.Target = u.info.Name
+	case config.ACTION_TYPE_MOVE:
+		a.Source, a.Target = from.info.Name, u.info.Name
+	default:
+		a.Source = u.info.Name
+	}
+	r.actions = append(r.actions, a)
+}
+
+func (r *renderer) place(j int, at time.Time) {
+	u := r.firstFree(idealGroup(r.m.temps[j]))
+	if u == nil {
+		u = r.firstFree(report.GroupShelf)
+	}
+	if u == nil {
+		// The model guarantees room; a nil unit here means the plan and the layout disagree.
+		return
+	}
+	u.orders[j] = true
+	r.location[j] = u
+	r.log(j, config.ACTION_TYPE_PLACE, nil, u, at)
+}
+
+func (r *renderer) remove(j int, action string, at time.Time) {
+	u := r.location[j]
+	r.log(j, action, nil, u, at)
+	delete(u.orders, j)
+	delete(r.location, j)
+}
+
+// refill moves a shelf order back into an ideal unit that just freed up.
+func (r *renderer) refill(freed *unit, at time.Time) {
+	if freed == nil || freed.info.Group == report.GroupShelf {
+		return
+	}
+	var from *unit
+	pick := -1
+	for _, shelf := range r.units[report.GroupShelf] {
+		for j := range shelf.orders {
+			if idealGroup(r.m.temps[j]) == freed.info.Group && (pick < 0 || j < pick) {
+				from, pick = shelf, j
+			}
+		}
+	}
+	if pick < 0 {
+		return
+	}
+	delete(from.orders, pick)
+	freed.orders[pick] = true
+	r.location[pick] = freed
+	r.log(pick, config.ACTION_TYPE_MOVE, from, freed, at)
+}
@@ -0,0 +1,193 @@
+package oracle
+
+import (
+	"math"
+	"math/rand"
+)
+
+// placeable reports whether an order could be stored at all in an empty system.
+func (m *model) placeable(job int) bool {
+	var c counts
+	c.add(m.temps[job], 1)
+	return m.fits(c)
+}
+
+// greedy evicts, whenever a placement does not fit, the stored order with the lowest
+// weighted value per second left until its pickup: the order that buys the least for
+// the space it holds. It returns the evictions and their objective value.
+func (m *model) greedy(weights []float64) (map[int]int, float64) {
+	stored := make([]bool, len(m.problem.Visits))
+	evictions := make(map[int]int)
+	var c counts
+	value := 0.0
+	for _, ev := range m.events {
+		j := ev.job
+		if ev.pickup {
+			if stored[j] {
+				stored[j] = false
+				c.add(m.temps[j], -1)
+				value += m.values[j]
+			}
+			continue
+		}
+		if !m.placeable(j) {
+			continue
+		}
+		c.add(m.temps[j], 1)
+		if !m.fits(c) {
+			victim, best := -1, math.Inf(1)
+			for x, ok := range stored {
+				if !ok {
+					continue
+				}
+				c.add(m.temps[x], -1)
+				if m.fits(c) {
+					left := m.problem.Visits[x].PickupAt.Sub(ev.at).Seconds()
+					key := weights[x] * m.values[x] / math.Max(left, 1e-3)
+					if key < best {
+						victim, best = x, key
+					}
+				}
+				c.add(m.temps[x], 1)
+			}
+			if victim < 0 {
+				c.add(m.temps[j], -1)
+				continue
+			}
+			stored[victim] = false
+			c.add(m.temps[victim], -1)
+			evictions[j] = victim
+		}
+		if m.doomed[j] {
+			c.add(m.temps[j], -1)
+		} else {
+			stored[j] = true
+		}
+	}
+	return evictions, value
+}
+
+// heuristic improves the greedy plan by local search over per-order eviction weights,
+// keeping perturbations that do not lower the objective.
+func (m *model) heuristic(opts Options) (map[int]int, float64) {
+	n := len(m.problem.Visits)
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	best, bestValue := m.greedy(weights)
+	if n == 0 {
+		return best, bestValue
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for it := 0; it < opts.Iterations; it++ {
+		candidate := append([]float64(nil), weights...)
+		for k := 0; k < 1+n/20; k++ {
+			i := rng.Intn(n)
+			candidate[i] *= math.Exp(rng.NormFloat64())
+		}
+		evictions, value := m.greedy(candidate)
+		if value >= bestValue {
+			weights, best, bestValue = candidate, evictions, value
+		}
+	}
+	return best, bestValue
+}
+
+// search is the state of the exact branch-and-bound solver.
+type search struct {
+	m         *model
+	stored    []bool
+	c         counts
+	evictions map[int]int
+	best      float64
+	bestPlan  map[int]int
+	nodes     int
+	limit     int
+	aborted   bool
+}
+
+// exact enumerates every eviction choice, pruning branches that cannot beat the incumbent.
+// It returns the best plan found and whether it is proven optimal.
+func (m *model) exact(incumbent map[int]int, value float64, nodeLimit int) (map[int]int, float64, bool) {
+	s := &search{
+		m:         m,
+		stored:    make([]bool, len(m.problem.Visits)),
+		evictions: make(map[int]int),
+		best:      value,
+		bestPlan:  incumbent,
+		limit:     nodeLimit,
+	}
+	s.dfs(0, 0, 0)
+	return s.bestPlan, s.best, !s.aborted
+}
+
+func (s *search) dfs(i int, value, storedValue float64) {
+	s.nodes++
+	if s.limit > 0 && s.nodes > s.limit {
+		s.aborted = true
+		return
+	}
+	if value+storedValue+s.m.upcoming[i] <= s.best+1e-9 {
+		return
+	}
+	if i == len(s.m.events) {
+		s.best = value
+		s.bestPlan = make(map[int]int, len(s.evictions))
+		for k, v := range s.evictions {
+			s.bestPlan[k] = v
+		}
+		return
+	}
+	ev := s.m.events[i]
+	j := ev.job
+	if ev.pickup {
+		if !s.stored[j] {
+			s.dfs(i+1, value, storedValue)
+			return
+		}
+		s.stored[j] = false
+		s.c.add(s.m.temps[j], -1)
+		s.dfs(i+1, value+s.m.values[j], storedValue-s.m.values[j])
+		s.c.add(s.m.temps[j], 1)
+		s.stored[j] = true
+		return
+	}
+	if !s.m.placeable(j) {
+		s.dfs(i+1, value, storedValue)
+		return
+	}
+	s.c.add(s.m.temps[j], 1)
+	if s.m.fits(s.c) {
+		s.place(i, j, value, storedValue)
+	} else {
+		for x, ok := range s.stored {
+			if !ok || s.aborted {
+				continue
+			}
+			s.c.add(s.m.temps[x], -1)
+			if s.m.fits(s.c) {
+				s.stored[x] = false
+				s.evictions[j] = x
+				s.place(i, j, value, storedValue-s.m.values[x])
+				delete(s.evictions, j)
+				s.stored[x] = true
+			}
+			s.c.add(s.m.temps[x], 1)
+		}
+	}
+	s.c.add(s.m.temps[j], -1)
+}
+
+// place stores job j (or discards it right away if doomed) and continues the search.
+func (s *search) place(i, j int, value, storedValue float64) {
+	if s.m.doomed[j] {
+		s.c.add(s.m.temps[j], -1)
+		s.dfs(i+1, value, storedValue)
+		s.c.add(s.m.temps[j], 1)
+		return
+	}
+	s.stored[j] = true
+	s.dfs(i+1, value, storedValue+s.m.values[j])
+	s.stored[j] = false
+}
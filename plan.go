@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"challenge/config"
+	"challenge/sim"
+)
+
+// runPlan implements the plan command: it searches storage configurations for the cheapest
+// one meeting a target discard rate and average freshness on a given workload.
+func runPlan(args []string) {
+	cmd := flag.NewFlagSet("plan", flag.ExitOnError)
+	ordersPath := cmd.String("orders", "", "Path to a JSON or JSONL file of orders (required)")
+	seeds := cmd.Int("seeds", 5, "Number of seeds per configuration")
+	firstSeed := cmd.Int64("seed", 1, "First seed; seeds are consecutive from here")
+	orderRate := cmd.Duration("rate", 500*time.Millisecond, "Inverse order rate (time between order placements)")
+	minPickup := cmd.Duration("min", 4*time.Second, "Minimum pickup time")
+	maxPickup := cmd.Duration("max", 8*time.Second, "Maximum pickup time")
+	arrivalSpec := cmd.String("arrival", "", "Arrival model, e.g. poisson:rate=2 (constant --rate if blank)")
+	coolers := cmd.String("coolers", "1:2", "Number of coolers, as n, min:max or min:max:step")
+	coolerCap := cmd.String("cooler-cap", "2:8:2", "Cooler capacity range")
+	heaters := cmd.String("heaters", "1:2", "Number of heaters range")
+	heaterCap := cmd.String("heater-cap", "2:8:2", "Heater capacity range")
+	shelves := cmd.String("shelves", "1", "Number of shelves range")
+	shelfCap := cmd.String("shelf-cap", "4:16:4", "Shelf capacity range")
+	costsPath := cmd.String("costs", "", "JSON file with per-unit and per-slot costs (defaults if blank)")
+	configPath := cmd.String("config", "", "Configuration file every candidate is built on, with the searched counts and capacities replacing its own (optional)")
+	maxDiscard := cmd.Float64("max-discard-rate", 0.05, "Target: highest acceptable mean discard rate")
+	minFreshness := cmd.Float64("min-freshness", 0.8, "Target: lowest acceptable mean freshness fraction at pickup")
+	mode := cmd.String("mode", sim.PlanGrid, "Search mode: grid or search")
+	top := cmd.Int("top", 10, "Number of cheapest configurations to print (all if 0)")
+	parallel := cmd.Int("parallel", runtime.NumCPU(), "Number of simulations to run concurrently")
+	out := cmd.String("out", "", "Write every evaluated configuration as JSON to this file (optional)")
+	cmd.Parse(args)
+
+	if *ordersPath == "" {
+		log.Fatalf("plan: --orders is required")
+	}
+	if *mode != sim.PlanGrid && *mode != sim.PlanSearch {
+		log.Fatalf("plan: unknown mode %q", *mode)
+	}
+	orders, stays, err := loadWorkload(*ordersPath)
+	if err != nil {
+		log.Fatalf("plan: %v", err)
+	}
+
+	var base config.FulfillmentConfig
+	if *configPath != "" {
+		if base, err = config.ReadConfig(*configPath); err != nil {
+			log.Fatalf("plan: %v", err)
+		}
+		if len(base.Coolers)+len(base.Heaters)+len(base.Shelves) > 0 {
+			log.Printf("plan: the units listed in %s are replaced by the searched counts and capacities", *configPath)
+			base.Coolers, base.Heaters, base.Shelves = nil, nil, nil
+		}
+	}
+	arrivals, err := arrivalModel(*arrivalSpec, base.Arrival, *orderRate)
+	if err != nil {
+		log.Fatalf("plan: %v", err)
+	}
+
+	spec := sim.PlanSpec{
+		Orders:         orders,
+		Rate:           *orderRate,
+		Arrivals:       arrivals,
+		Min:            *minPickup,
+		Max:            *maxPickup,
+		Stays:          stays,
+		Base:           base,
+		Costs:          sim.DefaultCosts(),
+		MaxDiscardRate: *maxDiscard,
+		MinFreshness:   *minFreshness,
+		Mode:           *mode,
+		Parallel:       *parallel,
+	}
+	for i := 0; i < *seeds; i++ {
+		spec.Seeds = append(spec.Seeds, *firstSeed+int64(i))
+	}
+	for _, r := range []struct {
+		dst  *sim.Range
+		text string
+	}{
+		{&spec.NumCoolers, *coolers}, {&spec.CoolerCap, *coolerCap},
+		{&spec.NumHeaters, *heaters}, {&spec.HeaterCap, *heaterCap},
+		{&spec.NumShelves, *shelves}, {&spec.ShelfCap, *shelfCap},
+	} {
+		if *r.dst, err = sim.ParseRange(r.text); err != nil {
+			log.Fatalf("plan: %v", err)
+		}
+	}
+	if *costsPath != "" {
+		data, err := os.ReadFile(*costsPath)
+		if err != nil {
+			log.Fatalf("plan: %v", err)
+		}
+		if err := json.Unmarshal(data, &spec.Costs); err != nil {
+			log.Fatalf("plan: failed to parse %v: %v", *costsPath, err)
+		}
+	}
+
+	log.Printf("Planning storage for %d orders (%s mode, %d seeds per configuration)", len(spec.Orders), spec.Mode, len(spec.Seeds))
+	log.SetOutput(io.Discard)
+	start := time.Now()
+	candidates := sim.Plan(spec)
+	log.SetOutput(os.Stderr)
+	log.Printf("Evaluated %d configurations in %v", len(candidates), time.Since(start).Round(time.Millisecond))
+
+	sim.PrintPlan(os.Stdout, candidates, *top)
+	if best, ok := sim.Cheapest(candidates); ok {
+		cfg := best.Config
+		fmt.Printf("\nCheapest configuration meeting the targets (cost %.0f): coolers %dx%d, heaters %dx%d, shelves %dx%d\n",
+			best.Cost, cfg.NumCoolers, cfg.CoolerCap, cfg.NumHeaters, cfg.HeaterCap, cfg.NumShelves, cfg.ShelfCap)
+	} else {
+		fmt.Println("\nNo configuration meets the targets; widen the ranges or relax the targets.")
+	}
+	if *out != "" {
+		data, err := json.MarshalIndent(candidates, "", "  ")
+		if err != nil {
+			log.Fatalf("plan: %v", err)
+		}
+		if err := os.WriteFile(*out, data, 0644); err != nil {
+			log.Fatalf("plan: %v", err)
+		}
+	}
+}
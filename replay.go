@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"challenge/actionlog"
+	"challenge/catalog"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/report"
+	"challenge/workload"
+)
+
+// runReplay implements the replay command: it rebuilds the storages from an exported
+// action log and lets the user step through the actions or jump to any instant.
+func runReplay(args []string) {
+	cmd := flag.NewFlagSet("replay", flag.ExitOnError)
+	actionsPath := cmd.String("actions", "", "Path to an action log exported as JSONL or CSV (required)")
+	ordersPath := cmd.String("orders", "", "Path to a JSON or JSONL file of the orders, for their names (optional)")
+	catalogPath := cmd.String("catalog", "", "Path to the JSON menu catalog the run used, for the sizes of the orders (optional)")
+	configPath := cmd.String("config", "config/init.json", "Path to the storage configuration the log was produced with")
+	at := cmd.String("at", "", "Print the storages at this instant, an offset from the first action such as 12.5s (bare numbers are seconds) or @ and unix microseconds, and exit")
+	step := cmd.Int("step", -1, "Print the storages after this many actions and exit")
+	cmd.Parse(args)
+
+	if *actionsPath == "" {
+		log.Fatalf("replay: --actions is required")
+	}
+	actions, err := actionlog.ImportFile(*actionsPath)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	var orders []entity.Order
+	if *ordersPath != "" {
+		clientOrders, err := workload.Load(*ordersPath)
+		if err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		if *catalogPath == "" {
+			orders = workload.ToOrders(clientOrders)
+		} else {
+			menu, err := catalog.Load(*catalogPath)
+			if err != nil {
+				log.Fatalf("replay: %v", err)
+			}
+			for _, o := range clientOrders {
+				// Mismatches were reported during the run; the sizes are all that is needed here.
+				order, _ := workload.ToMenuOrder(o, menu)
+				orders = append(orders, order)
+			}
+		}
+	}
+	cfg, err := config.ReadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	replay := report.NewReplay(report.LayoutFromConfig(cfg), orders, actions)
+	switch {
+	case *at != "":
+		t, err := replay.ParseTime(*at)
+		if err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		replay.SeekTime(t)
+		replay.Print(os.Stdout)
+	case *step >= 0:
+		replay.Seek(*step)
+		replay.Print(os.Stdout)
+	default:
+		replay.Print(os.Stdout)
+		replay.Console(os.Stdin, os.Stdout)
+	}
+	for _, err := range replay.Errors() {
+		log.Printf("Inconsistent action log: %v", err)
+	}
+}
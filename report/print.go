@@ -0,0 +1,126 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// occupancyRows is the maximum number of rows of the shelf occupancy timeline table.
+const occupancyRows = 20
+
+// Print writes the summary as human-readable tables.
+func Print(w io.Writer, s Summary) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Orders:\t%d\n", s.Orders)
+	fmt.Fprintf(tw, "Duration:\t%.1fs\n", s.Duration)
+	if s.Inconsistent > 0 {
+		fmt.Fprintf(tw, "Inconsistent actions:\t%d\n", s.Inconsistent)
+	}
+
+	fmt.Fprintln(tw, "\nACTION\tCOUNT")
+	var names []string
+	for name := range s.Actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%d\n", name, s.Actions[name])
+	}
+
+	fmt.Fprintln(tw, "\nTEMPERATURE\tORDERS\tPICKED UP\tDISCARDED\tDISCARD RATE")
+	for _, t := range s.Temperatures {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\n", t.Temperature, t.Orders, t.PickedUp, t.Discarded, 100*t.DiscardRate)
+	}
+
+	if len(s.Items) > 0 {
+		fmt.Fprintln(tw, "\nMENU ITEM\tORDERS\tPICKED UP\tDISCARDED\tDISCARD RATE\tWASTED VALUE")
+		for _, it := range s.Items {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%.2f\n", it.Name, it.Orders, it.PickedUp, it.Discarded, 100*it.DiscardRate, it.WastedValue)
+		}
+	}
+
+	f := s.Freshness
+	fmt.Fprintf(tw, "\nFRESHNESS AT PICKUP\tmean %.2f\tmedian %.2f\tmin %.2f\texpired %d\n", f.Mean, f.Median, f.Min, f.Expired)
+	for i, n := range f.Buckets {
+		lo, hi := float64(i)/FreshnessBuckets, float64(i+1)/FreshnessBuckets
+		fmt.Fprintf(tw, "%.1f-%.1f\t%d\t%s\n", lo, hi, n, bar(n, f.Count))
+	}
+
+	fmt.Fprintln(tw, "\nMOVES PER ORDER\tORDERS")
+	var counts []int
+	for moves := range s.MoveCounts {
+		counts = append(counts, moves)
+	}
+	sort.Ints(counts)
+	for _, moves := range counts {
+		fmt.Fprintf(tw, "%d\t%d\n", moves, s.MoveCounts[moves])
+	}
+
+	fmt.Fprintln(tw, "\nSTORAGE\tGROUP\tCAPACITY\tPEAK\tPEAK UTILIZATION\tPEAK AT")
+	for _, st := range s.Storages {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.0f%%\t%.1fs\n", st.Name, st.Group, st.Capacity, st.Peak, 100*st.PeakUtilization, st.PeakAt)
+	}
+
+	fmt.Fprintln(tw, "\nSHELF OCCUPANCY\tAVG\tPEAK")
+	for _, row := range occupancyTimeline(s.ShelfOccupancy, s.Duration) {
+		fmt.Fprintf(tw, "%.1fs-%.1fs\t%.1f\t%d\n", row.from, row.to, row.avg, row.peak)
+	}
+	tw.Flush()
+}
+
+// WriteJSON writes the summary as indented JSON to a file.
+func WriteJSON(path string, s Summary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+type occupancyRow struct {
+	from, to float64
+	avg      float64
+	peak     int
+}
+
+// occupancyTimeline buckets the occupancy step function into at most occupancyRows
+// intervals, with the time-weighted average and the peak of each interval.
+func occupancyTimeline(points []OccupancyPoint, duration float64) []occupancyRow {
+	if len(points) == 0 || duration <= 0 {
+		return nil
+	}
+	width := duration / occupancyRows
+	rows := make([]occupancyRow, occupancyRows)
+	for i := range rows {
+		rows[i].from = float64(i) * width
+		rows[i].to = float64(i+1) * width
+	}
+	for i, p := range points {
+		end := duration
+		if i+1 < len(points) {
+			end = points[i+1].Offset
+		}
+		for r := range rows {
+			lo, hi := max(p.Offset, rows[r].from), min(end, rows[r].to)
+			if hi > lo {
+				rows[r].avg += float64(p.Orders) * (hi - lo) / width
+			}
+			if p.Offset < rows[r].to && end > rows[r].from && p.Orders > rows[r].peak {
+				rows[r].peak = p.Orders
+			}
+		}
+	}
+	return rows
+}
+
+func bar(n, total int) string {
+	if total == 0 {
+		return ""
+	}
+	return strings.Repeat("#", n*40/total)
+}
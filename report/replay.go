@@ -0,0 +1,326 @@
+package report
+
+import (
+	"bufio"
+	"challenge/entity"
+	"challenge/logic"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Replay steps through an action log, reconstructing the storages at any action or instant.
+type Replay struct {
+	layout  []StorageInfo
+	orders  map[string]entity.Order
+	actions []logic.Action
+	pos     int   // Number of actions applied.
+	at      int64 // Instant viewed, in unix microseconds.
+	state   *State
+	last    map[string]logic.Action // Latest applied action on each stored order.
+	errs    []error                 // Inconsistencies found by the applied actions.
+}
+
+// StorageView is the content of a storage unit at the instant a Replay is at.
+type StorageView struct {
+	StorageInfo
+	Used   int         // Slots taken by the orders.
+	Orders []OrderView // Stored orders, least fresh first.
+}
+
+// OrderView is a stored order at the instant a Replay is at.
+type OrderView struct {
+	ID          string
+	Name        string
+	Temperature string
+	Freshness   time.Duration // Remaining freshness at that instant.
+	Since       int64         // Unix microseconds of the latest action on the order.
+}
+
+// NewReplay prepares a replay of the actions over the storage layout, before the first
+// action. Orders supply names, temperatures and sizes, and orders left out take one slot;
+// storages named by the actions but missing from the layout are added to it.
+func NewReplay(layout []StorageInfo, orders []entity.Order, actions []logic.Action) *Replay {
+	r := &Replay{layout: append([]StorageInfo(nil), layout...), orders: make(map[string]entity.Order), actions: actions}
+	known := make(map[string]bool)
+	for _, s := range layout {
+		known[s.Name] = true
+	}
+	for _, a := range actions {
+		if a.Storage != "" && !known[a.Storage] {
+			known[a.Storage] = true
+			r.layout = append(r.layout, StorageInfo{Name: a.Storage})
+		}
+	}
+	for _, o := range orders {
+		r.orders[o.ID] = o
+	}
+	r.Seek(0)
+	return r
+}
+
+// Len returns the number of actions in the log.
+func (r *Replay) Len() int {
+	return len(r.actions)
+}
+
+// Pos returns the number of actions applied so far.
+func (r *Replay) Pos() int {
+	return r.pos
+}
+
+// At returns the instant the replay is at.
+func (r *Replay) At() time.Time {
+	return time.UnixMicro(r.at)
+}
+
+// Start returns the instant of the first action, or the zero time if there is none.
+func (r *Replay) Start() time.Time {
+	if len(r.actions) == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(r.actions[0].Timestamp)
+}
+
+// Errors returns the inconsistencies found by the actions applied so far.
+func (r *Replay) Errors() []error {
+	return r.errs
+}
+
+// Seek moves to just after the first n actions, at the instant of the last of them.
+func (r *Replay) Seek(n int) {
+	n = max(0, min(n, len(r.actions)))
+	if n < r.pos || r.state == nil {
+		r.reset()
+	}
+	for r.pos < n {
+		r.apply(r.actions[r.pos])
+	}
+	switch {
+	case n > 0:
+		r.at = r.actions[n-1].Timestamp
+	case len(r.actions) > 0:
+		r.at = r.actions[0].Timestamp
+	}
+}
+
+// Step moves forward by n actions, or back if n is negative.
+func (r *Replay) Step(n int) {
+	r.Seek(r.pos + n)
+}
+
+// SeekTime moves to the given instant, with every action up to and including it applied.
+func (r *Replay) SeekTime(t time.Time) {
+	ts := t.UnixMicro()
+	n := sort.Search(len(r.actions), func(i int) bool { return r.actions[i].Timestamp > ts })
+	r.Seek(n)
+	r.at = ts
+}
+
+func (r *Replay) reset() {
+	r.state = NewState(r.layout)
+	for id, o := range r.orders {
+		r.state.Units[id] = o.Units()
+	}
+	r.last = make(map[string]logic.Action)
+	r.errs = nil
+	r.pos = 0
+}
+
+func (r *Replay) apply(a logic.Action) {
+	if err := r.state.Apply(a); err != nil {
+		r.errs = append(r.errs, fmt.Errorf("action %d: %v", r.pos+1, err))
+	}
+	if _, stored := r.state.Location[a.OrderID]; stored {
+		r.last[a.OrderID] = a
+	} else {
+		delete(r.last, a.OrderID)
+	}
+	r.pos++
+}
+
+// View returns the content of every storage at the current instant. The remaining
+// freshness of an order is the one recorded by its latest action, less the time since at
+// the decay rate recorded with it; changes in conditions since that action are not in
+// the log.
+func (r *Replay) View() []StorageView {
+	views := make([]StorageView, len(r.layout))
+	index := make(map[string]int)
+	for i, s := range r.layout {
+		views[i] = StorageView{StorageInfo: s, Used: r.state.Used[s.Name]}
+		index[s.Name] = i
+	}
+	for id, where := range r.state.Location {
+		i, ok := index[where]
+		if !ok {
+			continue
+		}
+		a := r.last[id]
+		o := r.orders[id]
+		temp := o.Temperature
+		if temp == "" {
+			temp = a.Temperature
+		}
+		v := &views[i]
+		v.Orders = append(v.Orders, OrderView{
+			ID:          id,
+			Name:        o.Name,
+			Temperature: temp,
+			Freshness:   a.Freshness - decayed(time.Duration(r.at-a.Timestamp)*time.Microsecond, a.Decay),
+			Since:       a.Timestamp,
+		})
+	}
+	for _, v := range views {
+		sort.Slice(v.Orders, func(i, j int) bool {
+			if v.Orders[i].Freshness != v.Orders[j].Freshness {
+				return v.Orders[i].Freshness < v.Orders[j].Freshness
+			}
+			return v.Orders[i].ID < v.Orders[j].ID
+		})
+	}
+	return views
+}
+
+// decayed returns the freshness used up over elapsed at a decay rate; zero counts as one.
+func decayed(elapsed time.Duration, rate float64) time.Duration {
+	if rate > 0 {
+		return time.Duration(float64(elapsed) * rate)
+	}
+	return elapsed
+}
+
+// Print writes the position of the replay, the action it is at and the content of every
+// storage.
+func (r *Replay) Print(w io.Writer) {
+	fmt.Fprintf(w, "Action %d/%d at +%v", r.pos, len(r.actions), r.At().Sub(r.Start()))
+	if r.pos > 0 {
+		a := r.actions[r.pos-1]
+		fmt.Fprintf(w, ": %s %s", a.Action, a.OrderID)
+		if a.Source != "" {
+			fmt.Fprintf(w, " from %s", a.Source)
+		}
+		if a.Target != "" {
+			fmt.Fprintf(w, " to %s", a.Target)
+		}
+		if a.Source == "" && a.Target == "" && a.Storage != "" {
+			fmt.Fprintf(w, " in %s", a.Storage)
+		}
+		if a.Rule != "" {
+			fmt.Fprintf(w, " (%s)", a.Rule)
+		}
+		if a.Reason != "" {
+			fmt.Fprintf(w, ": %s", a.Reason)
+		}
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, v := range r.View() {
+		fmt.Fprintf(tw, "%s\t%s\t%d/%d\n", v.Name, v.Group, v.Used, v.Capacity)
+		for _, o := range v.Orders {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%v\n", o.ID, o.Name, o.Temperature, o.Freshness.Round(time.Millisecond))
+		}
+	}
+	tw.Flush()
+}
+
+// replayUsage lists the commands understood by Replay.Exec.
+const replayUsage = "commands: next [n] | prev [n] | goto <action> | at <offset or @unix µs> | show | errors"
+
+// Exec runs a single replay command and returns its output:
+//
+//	next [n]       step forward n actions (1 by default)
+//	prev [n]       step back n actions (1 by default)
+//	goto <action>  move to just after the given action, counting from 1 (0 for the start)
+//	at <time>      move to an instant, given as an offset from the first action such as
+//	               12.5s (bare numbers are seconds) or as @ and a unix timestamp in
+//	               microseconds
+//	show           print the storages again
+//	errors         list the inconsistencies found in the log so far
+func (r *Replay) Exec(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	switch cmd := strings.ToLower(fields[0]); cmd {
+	case "next", "n", "prev", "p":
+		n := 1
+		if len(fields) > 1 {
+			var err error
+			if n, err = strconv.Atoi(fields[1]); err != nil {
+				return "", fmt.Errorf("invalid step %q", fields[1])
+			}
+		}
+		if cmd == "prev" || cmd == "p" {
+			n = -n
+		}
+		r.Step(n)
+	case "goto":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: goto <action>")
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid action number %q", fields[1])
+		}
+		r.Seek(n)
+	case "at":
+		if len(fields) != 2 {
+			return "", fmt.Errorf("usage: at <offset or @unix µs>")
+		}
+		t, err := r.ParseTime(fields[1])
+		if err != nil {
+			return "", err
+		}
+		r.SeekTime(t)
+	case "show":
+	case "errors":
+		var b strings.Builder
+		for _, err := range r.errs {
+			fmt.Fprintln(&b, err)
+		}
+		return b.String(), nil
+	default:
+		return "", fmt.Errorf("unknown command %q (%s)", fields[0], replayUsage)
+	}
+	var b strings.Builder
+	r.Print(&b)
+	return b.String(), nil
+}
+
+// ParseTime reads an instant given as an offset from the first action, such as 12.5s or a
+// bare number of seconds, or as @ followed by a unix timestamp in microseconds.
+func (r *Replay) ParseTime(s string) (time.Time, error) {
+	if ts, ok := strings.CutPrefix(s, "@"); ok {
+		us, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q, want @ and unix microseconds", s)
+		}
+		return time.UnixMicro(us), nil
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return r.Start().Add(time.Duration(secs * float64(time.Second))), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want an offset such as 12.5s or @ and unix microseconds", s)
+	}
+	return r.Start().Add(d), nil
+}
+
+// Console runs replay commands read one per line from in, writing their output and
+// errors to out, until in is exhausted.
+func (r *Replay) Console(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		s, err := r.Exec(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+			continue
+		}
+		fmt.Fprint(out, s)
+	}
+}
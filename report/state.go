@@ -0,0 +1,199 @@
+package report
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"fmt"
+)
+
+// Storage group names used in StorageInfo.
+const (
+	GroupCooler = logic.GroupCooler
+	GroupHeater = logic.GroupHeater
+	GroupShelf  = logic.GroupShelf
+)
+
+// StorageInfo describes a single storage unit of a fulfillment system.
+type StorageInfo struct {
+	Name         string   `json:"name"`
+	Group        string   `json:"group"`
+	Capacity     int      `json:"capacity"`
+	Temperatures []string `json:"temperatures,omitempty"` // Temperatures whose routes include the group; nil for the built-in routes.
+}
+
+// Holds reports whether the unit may store orders of a temperature.
+func (s StorageInfo) Holds(temperature string) bool {
+	if s.Temperatures == nil {
+		return Compatible(s.Group, temperature)
+	}
+	for _, t := range s.Temperatures {
+		if t == temperature {
+			return true
+		}
+	}
+	return false
+}
+
+// LayoutOf lists the storage units of a fulfillment system, coolers first, with the
+// temperatures its routes allow in each.
+func LayoutOf(fs *logic.FulfillmentSystem) []StorageInfo {
+	var layout []StorageInfo
+	for _, s := range fs.Snapshot().Storages {
+		temps := fs.Accepts(s.Group)
+		if temps == nil {
+			temps = []string{}
+		}
+		layout = append(layout, StorageInfo{Name: s.Name, Group: s.Group, Capacity: s.Capacity, Temperatures: temps})
+	}
+	return layout
+}
+
+// LayoutFromConfig lists the storage units a configuration creates, coolers first, with
+// the temperatures its routes allow in each, without starting a fulfillment system.
+func LayoutFromConfig(cfg config.FulfillmentConfig) []StorageInfo {
+	var layout []StorageInfo
+	for _, u := range logic.Layout(cfg) {
+		temps := u.Temperatures
+		if temps == nil {
+			temps = []string{}
+		}
+		layout = append(layout, StorageInfo{Name: u.Name, Group: u.Group, Capacity: u.Capacity, Temperatures: temps})
+	}
+	return layout
+}
+
+// State is the storage occupancy reconstructed by replaying an action log.
+type State struct {
+	Layout    []StorageInfo
+	Location  map[string]string // Order ID to the storage currently holding it.
+	Occupancy map[string]int    // Storage name to the number of orders it holds.
+	Used      map[string]int    // Storage name to the slots taken by its orders.
+	Units     map[string]int    // Order ID to the slots it takes up; unlisted orders take one.
+	Moves     map[string]int    // Order ID to the number of times it was moved.
+	Finished  map[string]string // Order ID to its terminal action (pickup or discard).
+}
+
+// NewState creates an empty state for the given storage layout.
+func NewState(layout []StorageInfo) *State {
+	st := &State{
+		Layout:    layout,
+		Location:  make(map[string]string),
+		Occupancy: make(map[string]int),
+		Used:      make(map[string]int),
+		Units:     make(map[string]int),
+		Moves:     make(map[string]int),
+		Finished:  make(map[string]string),
+	}
+	for _, s := range layout {
+		st.Occupancy[s.Name] = 0
+		st.Used[s.Name] = 0
+	}
+	return st
+}
+
+// SetSizes records the slots each order takes up, so Used accounts for order sizes.
+func (st *State) SetSizes(orders []entity.Order) {
+	for _, o := range orders {
+		st.Units[o.ID] = o.Units()
+	}
+}
+
+// units returns the slots an order takes up.
+func (st *State) units(orderID string) int {
+	if n, ok := st.Units[orderID]; ok {
+		return n
+	}
+	return 1
+}
+
+// take moves an order's slots out of one storage and into another; blank names are skipped.
+func (st *State) take(orderID, from, to string) {
+	n := st.units(orderID)
+	if from != "" {
+		st.Occupancy[from]--
+		st.Used[from] -= n
+	}
+	if to != "" {
+		st.Occupancy[to]++
+		st.Used[to] += n
+	}
+}
+
+// Apply updates the state with the next action of the log. The state is updated on a
+// best-effort basis even when the action is inconsistent with it, in which case an
+// error describing the inconsistency is returned.
+func (st *State) Apply(a logic.Action) error {
+	if done, ok := st.Finished[a.OrderID]; ok {
+		return fmt.Errorf("%s of order %s after it was already %s", a.Action, a.OrderID, pastTense(done))
+	}
+	current, placed := st.Location[a.OrderID]
+	var mismatch error
+	if placed && a.Source != "" && a.Source != current {
+		mismatch = fmt.Errorf("%s of order %s from %s while it is in %s", a.Action, a.OrderID, a.Source, current)
+	}
+	switch a.Action {
+	case config.ACTION_TYPE_PLACE:
+		if placed {
+			st.take(a.OrderID, current, a.Storage)
+			st.Location[a.OrderID] = a.Storage
+			return fmt.Errorf("order %s placed again while stored in %s", a.OrderID, current)
+		}
+		st.Location[a.OrderID] = a.Storage
+		st.take(a.OrderID, "", a.Storage)
+	case config.ACTION_TYPE_MOVE:
+		if !placed {
+			return fmt.Errorf("move of order %s that is not stored", a.OrderID)
+		}
+		st.take(a.OrderID, current, a.Storage)
+		st.Location[a.OrderID] = a.Storage
+		st.Moves[a.OrderID]++
+	case config.ACTION_TYPE_PIN, config.ACTION_TYPE_UNPIN:
+		if !placed {
+			return fmt.Errorf("%s of order %s that is not stored", a.Action, a.OrderID)
+		}
+	case config.ACTION_TYPE_PICKUP, config.ACTION_TYPE_DISCARD:
+		st.Finished[a.OrderID] = a.Action
+		if !placed {
+			// Orders rejected on arrival are discarded without a storage.
+			if a.Action == config.ACTION_TYPE_DISCARD && a.Storage == "" {
+				return mismatch
+			}
+			return fmt.Errorf("%s of order %s that is not stored", a.Action, a.OrderID)
+		}
+		st.take(a.OrderID, current, "")
+		delete(st.Location, a.OrderID)
+	default:
+		return fmt.Errorf("unknown action %q for order %s", a.Action, a.OrderID)
+	}
+	return mismatch
+}
+
+// GroupOccupancy returns the number of orders held by all storages of a group.
+func (st *State) GroupOccupancy(group string) int {
+	total := 0
+	for _, s := range st.Layout {
+		if s.Group == group {
+			total += st.Occupancy[s.Name]
+		}
+	}
+	return total
+}
+
+// GroupCapacity returns the combined capacity of all storages of a group.
+func (st *State) GroupCapacity(group string) int {
+	total := 0
+	for _, s := range st.Layout {
+		if s.Group == group {
+			total += s.Capacity
+		}
+	}
+	return total
+}
+
+func pastTense(action string) string {
+	if action == config.ACTION_TYPE_PICKUP {
+		return "picked up"
+	}
+	return action + "ed"
+}
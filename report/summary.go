@@ -0,0 +1,227 @@
+package report
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"math"
+	"sort"
+)
+
+// FreshnessBuckets is the number of equal-width buckets of the freshness fraction histogram.
+const FreshnessBuckets = 10
+
+// Summary holds the statistics of a single run.
+type Summary struct {
+	Orders         int                `json:"orders"`
+	Duration       float64            `json:"duration"` // seconds between the first and last action
+	Actions        map[string]int     `json:"actions"`
+	Temperatures   []TemperatureStats `json:"temperatures"`
+	Items          []ItemStats        `json:"items,omitempty"` // by menu item, most discarded first
+	Freshness      FreshnessStats     `json:"freshness_at_pickup"`
+	ShelfOccupancy []OccupancyPoint   `json:"shelf_occupancy"`
+	MoveCounts     map[int]int        `json:"move_counts"` // number of moves to the number of orders moved that often
+	Storages       []StorageStats     `json:"storages"`
+	Inconsistent   int                `json:"inconsistent_actions"`
+}
+
+// TemperatureStats holds outcome counts for orders of one temperature.
+type TemperatureStats struct {
+	Temperature string  `json:"temperature"`
+	Orders      int     `json:"orders"`
+	PickedUp    int     `json:"picked_up"`
+	Discarded   int     `json:"discarded"`
+	DiscardRate float64 `json:"discard_rate"`
+}
+
+// ItemStats holds outcome counts and wasted value for orders of one menu item.
+type ItemStats struct {
+	Name        string  `json:"name"`
+	Orders      int     `json:"orders"`
+	PickedUp    int     `json:"picked_up"`
+	Discarded   int     `json:"discarded"`
+	DiscardRate float64 `json:"discard_rate"`
+	WastedValue float64 `json:"wasted_value"` // menu value of the discarded orders
+}
+
+// FreshnessStats describes the remaining freshness fraction of orders at pickup,
+// where 1 means as fresh as when placed and 0 means expired.
+type FreshnessStats struct {
+	Count   int     `json:"count"`
+	Mean    float64 `json:"mean"`
+	Min     float64 `json:"min"`
+	Median  float64 `json:"median"`
+	Expired int     `json:"expired"` // picked up with no freshness left
+	Buckets []int   `json:"buckets"` // FreshnessBuckets equal-width buckets over [0, 1]
+}
+
+// OccupancyPoint is the shelf group occupancy right after an action.
+type OccupancyPoint struct {
+	Offset float64 `json:"offset"` // seconds since the first action
+	Orders int     `json:"orders"`
+}
+
+// StorageStats holds the peak utilization of a single storage unit.
+type StorageStats struct {
+	Name            string  `json:"name"`
+	Group           string  `json:"group"`
+	Capacity        int     `json:"capacity"`
+	Peak            int     `json:"peak"` // most slots used at once
+	PeakUtilization float64 `json:"peak_utilization"`
+	PeakAt          float64 `json:"peak_at"` // seconds since the first action
+}
+
+// Summarize computes the statistics of a run from its orders and action log.
+func Summarize(orders []entity.Order, actions []logic.Action, layout []StorageInfo) Summary {
+	byID := make(map[string]entity.Order, len(orders))
+	for _, o := range orders {
+		byID[o.ID] = o
+	}
+	summary := Summary{
+		Orders:     len(orders),
+		Actions:    make(map[string]int),
+		MoveCounts: make(map[int]int),
+		Freshness:  FreshnessStats{Buckets: make([]int, FreshnessBuckets)},
+	}
+
+	temps := make(map[string]*TemperatureStats)
+	tempOf := func(orderID string) *TemperatureStats {
+		temp := "unknown"
+		if o, ok := byID[orderID]; ok {
+			temp = o.Temperature
+		}
+		if temps[temp] == nil {
+			temps[temp] = &TemperatureStats{Temperature: temp}
+		}
+		return temps[temp]
+	}
+	items := make(map[string]*ItemStats)
+	itemOf := func(orderID string) *ItemStats {
+		o, ok := byID[orderID]
+		if !ok || o.Name == "" {
+			return &ItemStats{}
+		}
+		if items[o.Name] == nil {
+			items[o.Name] = &ItemStats{Name: o.Name}
+		}
+		return items[o.Name]
+	}
+	for _, o := range orders {
+		tempOf(o.ID).Orders++
+		itemOf(o.ID).Orders++
+	}
+
+	stats := make(map[string]*StorageStats)
+	for _, s := range layout {
+		stats[s.Name] = &StorageStats{Name: s.Name, Group: s.Group, Capacity: s.Capacity}
+	}
+
+	var fractions []float64
+	var start int64
+	st := NewState(layout)
+	st.SetSizes(orders)
+	for i, a := range actions {
+		if i == 0 {
+			start = a.Timestamp
+		}
+		offset := float64(a.Timestamp-start) / 1e6
+		summary.Duration = offset
+		summary.Actions[a.Action]++
+		if err := st.Apply(a); err != nil {
+			summary.Inconsistent++
+		}
+
+		switch a.Action {
+		case config.ACTION_TYPE_PICKUP:
+			tempOf(a.OrderID).PickedUp++
+			itemOf(a.OrderID).PickedUp++
+			if o, ok := byID[a.OrderID]; ok && o.ShelfLife() > 0 {
+				fractions = append(fractions, math.Max(0, math.Min(1, float64(a.Freshness)/float64(o.ShelfLife()))))
+			}
+		case config.ACTION_TYPE_DISCARD:
+			tempOf(a.OrderID).Discarded++
+			item := itemOf(a.OrderID)
+			item.Discarded++
+			item.WastedValue += byID[a.OrderID].Value
+		}
+
+		if s, ok := stats[a.Storage]; ok && st.Used[a.Storage] > s.Peak {
+			s.Peak = st.Used[a.Storage]
+			s.PeakAt = offset
+		}
+		shelf := st.GroupOccupancy(GroupShelf)
+		if n := len(summary.ShelfOccupancy); n == 0 || summary.ShelfOccupancy[n-1].Orders != shelf {
+			summary.ShelfOccupancy = append(summary.ShelfOccupancy, OccupancyPoint{Offset: offset, Orders: shelf})
+		}
+	}
+
+	for _, o := range orders {
+		summary.MoveCounts[st.Moves[o.ID]]++
+	}
+	for _, s := range layout {
+		ss := stats[s.Name]
+		if ss.Capacity > 0 {
+			ss.PeakUtilization = float64(ss.Peak) / float64(ss.Capacity)
+		}
+		summary.Storages = append(summary.Storages, *ss)
+	}
+	for _, ts := range temps {
+		if ts.Orders > 0 {
+			ts.DiscardRate = float64(ts.Discarded) / float64(ts.Orders)
+		}
+		summary.Temperatures = append(summary.Temperatures, *ts)
+	}
+	sort.Slice(summary.Temperatures, func(i, j int) bool {
+		return summary.Temperatures[i].Temperature < summary.Temperatures[j].Temperature
+	})
+	for _, is := range items {
+		is.DiscardRate = float64(is.Discarded) / float64(is.Orders)
+		summary.Items = append(summary.Items, *is)
+	}
+	sort.Slice(summary.Items, func(i, j int) bool {
+		a, b := summary.Items[i], summary.Items[j]
+		if a.Discarded != b.Discarded {
+			return a.Discarded > b.Discarded
+		}
+		return a.Name < b.Name
+	})
+	summary.Freshness = freshnessStats(fractions)
+	return summary
+}
+
+// DiscardRate returns the fraction of all orders that were discarded.
+func (s Summary) DiscardRate() float64 {
+	if s.Orders == 0 {
+		return 0
+	}
+	return float64(s.Actions[config.ACTION_TYPE_DISCARD]) / float64(s.Orders)
+}
+
+func freshnessStats(fractions []float64) FreshnessStats {
+	fs := FreshnessStats{Count: len(fractions), Buckets: make([]int, FreshnessBuckets)}
+	if len(fractions) == 0 {
+		return fs
+	}
+	sort.Float64s(fractions)
+	sum := 0.0
+	for _, f := range fractions {
+		sum += f
+		if f <= 0 {
+			fs.Expired++
+		}
+		b := int(f * FreshnessBuckets)
+		if b >= FreshnessBuckets {
+			b = FreshnessBuckets - 1
+		}
+		fs.Buckets[b]++
+	}
+	fs.Mean = sum / float64(len(fractions))
+	fs.Min = fractions[0]
+	mid := len(fractions) / 2
+	if len(fractions)%2 == 0 {
+		fs.Median = (fractions[mid-1] + fractions[mid]) / 2
+	} else {
+		fs.Median = fractions[mid]
+	}
+	return fs
+}
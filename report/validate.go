@@ -0,0 +1,105 @@
+package report
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"fmt"
+)
+
+// Violation describes a break of the fulfillment rules found in an action log.
+type Violation struct {
+	Index   int    `json:"index"` // Index of the offending action, -1 for checks at the end of the log.
+	OrderID string `json:"order_id"`
+	Message string `json:"message"`
+}
+
+func (v Violation) Error() string {
+	if v.Index < 0 {
+		return fmt.Sprintf("order %s: %s", v.OrderID, v.Message)
+	}
+	return fmt.Sprintf("action %d (order %s): %s", v.Index, v.OrderID, v.Message)
+}
+
+// Validate checks an action log offline against the orders and the storage layout:
+// timestamps must not decrease, every order must be placed once and then picked up or
+// discarded, storages must never exceed their capacity, units may only hold orders whose
+// routes include their group (by default, coolers and heaters only hold cold and hot
+// orders respectively), and no order may be picked up without freshness left.
+// Storage and freshness checks are skipped for actions without a storage name, such as
+// those imported from a solution payload.
+func Validate(orders []entity.Order, actions []logic.Action, layout []StorageInfo) []Violation {
+	var violations []Violation
+	report := func(i int, orderID, format string, args ...interface{}) {
+		violations = append(violations, Violation{Index: i, OrderID: orderID, Message: fmt.Sprintf(format, args...)})
+	}
+
+	byID := make(map[string]entity.Order, len(orders))
+	for _, o := range orders {
+		byID[o.ID] = o
+	}
+	units := make(map[string]StorageInfo, len(layout))
+	for _, s := range layout {
+		units[s.Name] = s
+	}
+
+	st := NewState(layout)
+	st.SetSizes(orders)
+	var last int64
+	for i, a := range actions {
+		if i > 0 && a.Timestamp < last {
+			report(i, a.OrderID, "timestamp %d is before the previous action's %d", a.Timestamp, last)
+		}
+		last = a.Timestamp
+
+		order, known := byID[a.OrderID]
+		if !known {
+			report(i, a.OrderID, "unknown order")
+		}
+		if err := st.Apply(a); err != nil {
+			report(i, a.OrderID, "%v", err)
+		}
+		if a.Storage == "" {
+			continue
+		}
+
+		unit, ok := units[a.Storage]
+		if !ok {
+			report(i, a.OrderID, "unknown storage %s", a.Storage)
+			continue
+		}
+		if (a.Action == config.ACTION_TYPE_PLACE || a.Action == config.ACTION_TYPE_MOVE) && st.Used[unit.Name] > unit.Capacity {
+			report(i, a.OrderID, "%s holds %d slots of orders, over its capacity of %d", unit.Name, st.Used[unit.Name], unit.Capacity)
+		}
+		if known && !unit.Holds(order.Temperature) {
+			report(i, a.OrderID, "%s order stored in %s %s", order.Temperature, unit.Group, unit.Name)
+		}
+		if a.Action == config.ACTION_TYPE_PICKUP && a.Freshness <= 0 {
+			report(i, a.OrderID, "picked up with no freshness left (%v)", a.Freshness)
+		}
+	}
+
+	for _, o := range orders {
+		if _, done := st.Finished[o.ID]; done {
+			continue
+		}
+		if where, stored := st.Location[o.ID]; stored {
+			report(-1, o.ID, "still stored in %s at the end of the log", where)
+		} else {
+			report(-1, o.ID, "never placed")
+		}
+	}
+	return violations
+}
+
+// Compatible reports whether an order of the given temperature may be stored in a group
+// by the built-in routes.
+func Compatible(group, temperature string) bool {
+	switch group {
+	case GroupCooler:
+		return temperature == config.TEMP_TYPE_COLD
+	case GroupHeater:
+		return temperature == config.TEMP_TYPE_HOT
+	}
+	return true
+}
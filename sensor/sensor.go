@@ -0,0 +1,110 @@
+// Package sensor simulates the temperature inside a storage unit and how it speeds up or
+// slows down the decay of the orders stored there.
+package sensor
+
+import (
+	"challenge/config"
+	"math"
+	"sort"
+	"time"
+)
+
+// Sensor is a simulated temperature sensor. The temperature follows a steady drift from
+// the setpoint, plus a jump whenever the door opens that fades linearly over the recovery
+// time. A Sensor is not safe for concurrent use.
+type Sensor struct {
+	drift     float64       // Degrees per minute.
+	doorDelta float64       // Degrees added when the door opens.
+	doorEvery time.Duration // Period of scheduled door openings; none if zero.
+	recovery  time.Duration // Time for a door opening to fade out.
+	curve     []config.DecayPoint
+
+	base   float64     // Temperature without door openings at baseAt.
+	baseAt time.Time   // Time of the latest drift change, or the start.
+	start  time.Time   // Start of the scheduled door openings.
+	doors  []time.Time // Door openings triggered by hand.
+}
+
+// New creates a sensor reading the setpoint at start.
+func New(c config.SensorConfig, start time.Time) *Sensor {
+	curve := append([]config.DecayPoint(nil), c.Decay...)
+	sort.Slice(curve, func(i, j int) bool { return curve[i].TempC < curve[j].TempC })
+	return &Sensor{
+		drift:     c.DriftCPerMin,
+		doorDelta: c.DoorOpenDeltaC,
+		doorEvery: seconds(c.DoorOpenEveryS),
+		recovery:  seconds(c.RecoveryS),
+		curve:     curve,
+		base:      c.SetpointC,
+		baseAt:    start,
+		start:     start,
+	}
+}
+
+// TemperatureAt returns the temperature at t, in degrees Celsius.
+func (s *Sensor) TemperatureAt(t time.Time) float64 {
+	temp := s.base + s.drift*t.Sub(s.baseAt).Minutes()
+	excursion := 0.0
+	if elapsed := t.Sub(s.start); s.doorEvery > 0 && elapsed >= s.doorEvery {
+		excursion = s.excursion(elapsed % s.doorEvery)
+	}
+	for _, opened := range s.doors {
+		if !t.Before(opened) {
+			excursion = math.Max(excursion, s.excursion(t.Sub(opened)))
+		}
+	}
+	return temp + excursion
+}
+
+// excursion returns the temperature added by a door opening some time ago.
+func (s *Sensor) excursion(since time.Duration) float64 {
+	if since >= s.recovery {
+		return 0
+	}
+	return s.doorDelta * (1 - float64(since)/float64(s.recovery))
+}
+
+// MultiplierAt returns the decay multiplier for the temperature at t.
+func (s *Sensor) MultiplierAt(t time.Time) float64 {
+	return Multiplier(s.curve, s.TemperatureAt(t))
+}
+
+// OpenDoor records a door opening at t.
+func (s *Sensor) OpenDoor(t time.Time) {
+	kept := s.doors[:0]
+	for _, opened := range s.doors {
+		if t.Sub(opened) < s.recovery {
+			kept = append(kept, opened)
+		}
+	}
+	s.doors = append(kept, t)
+}
+
+// SetDrift changes the drift from t on, keeping the temperature continuous.
+func (s *Sensor) SetDrift(t time.Time, degreesPerMinute float64) {
+	s.base += s.drift * t.Sub(s.baseAt).Minutes()
+	s.baseAt = t
+	s.drift = degreesPerMinute
+}
+
+// Multiplier interpolates the decay multiplier at a temperature from points sorted by
+// temperature. It returns 1 without points.
+func Multiplier(curve []config.DecayPoint, temp float64) float64 {
+	n := len(curve)
+	if n == 0 {
+		return 1
+	}
+	i := sort.Search(n, func(i int) bool { return curve[i].TempC > temp })
+	switch {
+	case i == 0:
+		return curve[0].Multiplier
+	case i == n:
+		return curve[n-1].Multiplier
+	}
+	prev, next := curve[i-1], curve[i]
+	return prev.Multiplier + (next.Multiplier-prev.Multiplier)*(temp-prev.TempC)/(next.TempC-prev.TempC)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,228 @@
+package sim
+
+import (
+	"challenge/arrival"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Strategy pairs a placement policy with a discard policy.
+type Strategy struct {
+	Placement string
+	Discard   string
+}
+
+// String formats the strategy as "placement/discard".
+func (s Strategy) String() string {
+	return s.Placement + "/" + s.Discard
+}
+
+// ParseStrategy parses "placement/discard"; a bare discard policy name uses the default placement.
+func ParseStrategy(text string) (Strategy, error) {
+	placement, discard := logic.PlacementIdealFirst, text
+	if i := strings.Index(text, "/"); i >= 0 {
+		placement, discard = text[:i], text[i+1:]
+	}
+	if !contains(logic.PlacementPolicyNames(), placement) {
+		return Strategy{}, fmt.Errorf("unknown placement policy %q (have %v)", placement, logic.PlacementPolicyNames())
+	}
+	if _, ok := logic.LookupDiscardPolicy(discard); !ok {
+		return Strategy{}, fmt.Errorf("unknown discard policy %q (have %v)", discard, logic.DiscardPolicyNames())
+	}
+	return Strategy{Placement: placement, Discard: discard}, nil
+}
+
+// AllStrategies lists every combination of registered placement and discard policies.
+func AllStrategies() []Strategy {
+	var all []Strategy
+	for _, p := range logic.PlacementPolicyNames() {
+		for _, d := range logic.DiscardPolicyNames() {
+			all = append(all, Strategy{Placement: p, Discard: d})
+		}
+	}
+	return all
+}
+
+// Variant is a named storage configuration.
+type Variant struct {
+	Name   string
+	Config config.FulfillmentConfig
+}
+
+// BenchSpec describes a tournament: every strategy is run on every configuration for every seed.
+type BenchSpec struct {
+	Orders     []entity.Order
+	Rate       time.Duration
+	Arrivals   arrival.Model // Arrival process; orders arrive every Rate if nil.
+	Min        time.Duration
+	Max        time.Duration
+	Stays      []Stay // Recorded arrivals and pickups, as in Scenario.
+	Seeds      []int64
+	Strategies []Strategy
+	Configs    []Variant
+	Parallel   int // Number of concurrent runs; values below 1 run sequentially.
+}
+
+// Stat is a sample mean with the half-width of its 95% confidence interval.
+type Stat struct {
+	Mean float64 `json:"mean"`
+	CI   float64 `json:"ci95"`
+}
+
+// Entry aggregates the runs of one strategy on one configuration.
+type Entry struct {
+	Strategy    string `json:"strategy"`
+	Config      string `json:"config"`
+	Runs        int    `json:"runs"`
+	Invalid     int    `json:"invalid"`
+	Score       Stat   `json:"score"`
+	DiscardRate Stat   `json:"discard_rate"`
+	Freshness   Stat   `json:"freshness"`
+}
+
+type benchJob struct {
+	entry int
+	cfg   config.FulfillmentConfig
+	seed  int64
+}
+
+type benchSample struct {
+	score, discardRate, freshness float64
+	valid                         bool
+}
+
+// Bench runs the tournament in parallel and returns the entries ranked by mean score.
+func Bench(spec BenchSpec) []Entry {
+	var entries []Entry
+	var jobs []benchJob
+	for _, v := range spec.Configs {
+		for _, s := range spec.Strategies {
+			cfg := v.Config
+			cfg.PlacementPolicy, cfg.DiscardPolicy = s.Placement, s.Discard
+			for _, seed := range spec.Seeds {
+				jobs = append(jobs, benchJob{entry: len(entries), cfg: cfg, seed: seed})
+			}
+			entries = append(entries, Entry{Strategy: s.String(), Config: v.Name})
+		}
+	}
+
+	samples := make([][]benchSample, len(entries))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan benchJob)
+	workers := spec.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				r := Run(job.cfg, Scenario{Orders: spec.Orders, Rate: spec.Rate, Arrivals: spec.Arrivals, Min: spec.Min, Max: spec.Max, Seed: job.seed, Stays: spec.Stays})
+				sample := benchSample{
+					score:       r.Score(),
+					discardRate: r.Summary.DiscardRate(),
+					freshness:   r.Summary.Freshness.Mean,
+					valid:       r.Valid(),
+				}
+				mu.Lock()
+				samples[job.entry] = append(samples[job.entry], sample)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	for i := range entries {
+		var scores, discards, freshness []float64
+		for _, s := range samples[i] {
+			if !s.valid {
+				entries[i].Invalid++
+			}
+			scores = append(scores, s.score)
+			discards = append(discards, s.discardRate)
+			freshness = append(freshness, s.freshness)
+		}
+		entries[i].Runs = len(samples[i])
+		entries[i].Score = NewStat(scores)
+		entries[i].DiscardRate = NewStat(discards)
+		entries[i].Freshness = NewStat(freshness)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Score.Mean > entries[j].Score.Mean
+	})
+	return entries
+}
+
+// PrintRanking writes the ranked entries as a table.
+func PrintRanking(w io.Writer, entries []Entry) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSTRATEGY\tCONFIG\tRUNS\tINVALID\tSCORE\tDISCARD RATE\tFRESHNESS")
+	for i, e := range entries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", i+1, e.Strategy, e.Config, e.Runs, e.Invalid,
+			e.Score, e.DiscardRate, e.Freshness)
+	}
+	tw.Flush()
+}
+
+// String formats the statistic as "mean ± ci".
+func (s Stat) String() string {
+	return fmt.Sprintf("%.3f ± %.3f", s.Mean, s.CI)
+}
+
+// NewStat computes the mean and the 95% confidence interval half-width of a sample
+// using Student's t distribution.
+func NewStat(xs []float64) Stat {
+	n := len(xs)
+	if n == 0 {
+		return Stat{}
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(n)
+	if n < 2 {
+		return Stat{Mean: mean}
+	}
+	ss := 0.0
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	stddev := math.Sqrt(ss / float64(n-1))
+	return Stat{Mean: mean, CI: tCritical95(n-1) * stddev / math.Sqrt(float64(n))}
+}
+
+// tCritical95 returns the two-sided 95% critical value of Student's t distribution.
+func tCritical95(df int) float64 {
+	table := []float64{12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+		2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+		2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042}
+	if df >= 1 && df <= len(table) {
+		return table[df-1]
+	}
+	return 1.96
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
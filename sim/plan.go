@@ -0,0 +1,282 @@
+package sim
+
+import (
+	"challenge/arrival"
+	"challenge/config"
+	"challenge/entity"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// UnitCost prices one storage unit: a fixed cost per unit plus a cost per slot of capacity.
+type UnitCost struct {
+	Unit float64 `json:"unit"`
+	Slot float64 `json:"slot"`
+}
+
+// Costs prices every kind of storage unit.
+type Costs struct {
+	Cooler UnitCost `json:"cooler"`
+	Heater UnitCost `json:"heater"`
+	Shelf  UnitCost `json:"shelf"`
+}
+
+// DefaultCosts returns costs where ideal storage is much more expensive than shelving.
+func DefaultCosts() Costs {
+	return Costs{
+		Cooler: UnitCost{Unit: 100, Slot: 10},
+		Heater: UnitCost{Unit: 100, Slot: 10},
+		Shelf:  UnitCost{Unit: 20, Slot: 2},
+	}
+}
+
+// Of returns the total cost of a storage configuration.
+func (c Costs) Of(cfg config.FulfillmentConfig) float64 {
+	return float64(cfg.NumCoolers)*(c.Cooler.Unit+c.Cooler.Slot*float64(cfg.CoolerCap)) +
+		float64(cfg.NumHeaters)*(c.Heater.Unit+c.Heater.Slot*float64(cfg.HeaterCap)) +
+		float64(cfg.NumShelves)*(c.Shelf.Unit+c.Shelf.Slot*float64(cfg.ShelfCap))
+}
+
+// Range is an inclusive range of integers visited in steps.
+type Range struct {
+	Min, Max, Step int
+}
+
+// ParseRange parses "n", "min:max" or "min:max:step".
+func ParseRange(text string) (Range, error) {
+	parts := strings.Split(text, ":")
+	if len(parts) > 3 {
+		return Range{}, fmt.Errorf("invalid range %q", text)
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 {
+			return Range{}, fmt.Errorf("invalid range %q", text)
+		}
+		nums[i] = n
+	}
+	r := Range{Min: nums[0], Max: nums[0], Step: 1}
+	if len(nums) > 1 {
+		r.Max = nums[1]
+	}
+	if len(nums) > 2 {
+		r.Step = nums[2]
+	}
+	if r.Max < r.Min || r.Step < 1 {
+		return Range{}, fmt.Errorf("invalid range %q", text)
+	}
+	return r, nil
+}
+
+// Values lists the integers of the range.
+func (r Range) Values() []int {
+	var values []int
+	for v := r.Min; v <= r.Max; v += r.Step {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Plan search modes.
+const (
+	PlanGrid   = "grid"   // Evaluate every configuration of the ranges.
+	PlanSearch = "search" // Greedily shrink the largest configuration while it meets the targets.
+)
+
+// PlanSpec describes a capacity planning problem.
+type PlanSpec struct {
+	Orders   []entity.Order
+	Rate     time.Duration
+	Arrivals arrival.Model // Arrival process; orders arrive every Rate if nil.
+	Min      time.Duration
+	Max      time.Duration
+	Stays    []Stay // Recorded arrivals and pickups, as in Scenario.
+	Seeds    []int64
+
+	// Searched dimensions, in the order of the configuration fields.
+	NumCoolers, CoolerCap, NumHeaters, HeaterCap, NumShelves, ShelfCap Range
+
+	Base           config.FulfillmentConfig // Configuration every candidate is built on; the searched dimensions replace its own.
+	Costs          Costs
+	MaxDiscardRate float64 // Target: highest acceptable mean discard rate.
+	MinFreshness   float64 // Target: lowest acceptable mean freshness fraction at pickup.
+	Mode           string
+	Parallel       int
+}
+
+// Candidate is an evaluated storage configuration.
+type Candidate struct {
+	Config      config.FulfillmentConfig `json:"config"`
+	Cost        float64                  `json:"cost"`
+	DiscardRate Stat                     `json:"discard_rate"`
+	Freshness   Stat                     `json:"freshness"`
+	Invalid     int                      `json:"invalid"`
+	Feasible    bool                     `json:"feasible"`
+}
+
+// Plan evaluates storage configurations and returns them sorted by cost, cheapest first.
+// The cheapest feasible candidate, if any, is the recommendation.
+func Plan(spec PlanSpec) []Candidate {
+	var evaluated []Candidate
+	if spec.Mode == PlanSearch {
+		evaluated = planSearch(spec)
+	} else {
+		evaluated = evaluate(spec, planGrid(spec))
+	}
+	sort.SliceStable(evaluated, func(i, j int) bool {
+		return evaluated[i].Cost < evaluated[j].Cost
+	})
+	return evaluated
+}
+
+// Cheapest returns the cheapest feasible candidate.
+func Cheapest(candidates []Candidate) (Candidate, bool) {
+	for _, c := range candidates {
+		if c.Feasible {
+			return c, true
+		}
+	}
+	return Candidate{}, false
+}
+
+// dims returns pointers to the searched fields of cfg alongside their ranges.
+func dims(spec PlanSpec, cfg *config.FulfillmentConfig) ([]*int, []Range) {
+	return []*int{&cfg.NumCoolers, &cfg.CoolerCap, &cfg.NumHeaters, &cfg.HeaterCap, &cfg.NumShelves, &cfg.ShelfCap},
+		[]Range{spec.NumCoolers, spec.CoolerCap, spec.NumHeaters, spec.HeaterCap, spec.NumShelves, spec.ShelfCap}
+}
+
+func planGrid(spec PlanSpec) []config.FulfillmentConfig {
+	configs := []config.FulfillmentConfig{spec.Base}
+	for d := 0; d < 6; d++ {
+		var next []config.FulfillmentConfig
+		for _, cfg := range configs {
+			_, ranges := dims(spec, &cfg)
+			for _, v := range ranges[d].Values() {
+				c := cfg
+				fields, _ := dims(spec, &c)
+				*fields[d] = v
+				next = append(next, c)
+			}
+		}
+		configs = next
+	}
+	return configs
+}
+
+// planSearch starts from the largest configuration and repeatedly takes the single-step
+// reduction that saves the most while still meeting the targets.
+func planSearch(spec PlanSpec) []Candidate {
+	current := spec.Base
+	fields, ranges := dims(spec, &current)
+	for d := range fields {
+		*fields[d] = ranges[d].Max
+	}
+	evaluated := evaluate(spec, []config.FulfillmentConfig{current})
+	if !evaluated[0].Feasible {
+		return evaluated
+	}
+	for {
+		var steps []config.FulfillmentConfig
+		for d := range fields {
+			if *fields[d]-ranges[d].Step < ranges[d].Min {
+				continue
+			}
+			c := current
+			cf, _ := dims(spec, &c)
+			*cf[d] -= ranges[d].Step
+			steps = append(steps, c)
+		}
+		if len(steps) == 0 {
+			return evaluated
+		}
+		results := evaluate(spec, steps)
+		evaluated = append(evaluated, results...)
+		best := -1
+		for i, r := range results {
+			if r.Feasible && (best < 0 || r.Cost < results[best].Cost) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return evaluated
+		}
+		current = results[best].Config
+	}
+}
+
+// evaluate simulates every configuration for every seed in parallel.
+func evaluate(spec PlanSpec, configs []config.FulfillmentConfig) []Candidate {
+	type job struct {
+		index int
+		seed  int64
+	}
+	samples := make([][]Result, len(configs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan job)
+	workers := spec.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				r := Run(configs[j.index], Scenario{Orders: spec.Orders, Rate: spec.Rate, Arrivals: spec.Arrivals, Min: spec.Min, Max: spec.Max, Seed: j.seed, Stays: spec.Stays})
+				r.Actions = nil // Only the summary is needed; let the log be collected.
+				mu.Lock()
+				samples[j.index] = append(samples[j.index], r)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range configs {
+		for _, seed := range spec.Seeds {
+			queue <- job{index: i, seed: seed}
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	candidates := make([]Candidate, len(configs))
+	for i, cfg := range configs {
+		var discards, freshness []float64
+		c := Candidate{Config: cfg, Cost: spec.Costs.Of(cfg)}
+		for _, r := range samples[i] {
+			if !r.Valid() {
+				c.Invalid++
+			}
+			discards = append(discards, r.Summary.DiscardRate())
+			freshness = append(freshness, r.Summary.Freshness.Mean)
+		}
+		c.DiscardRate = NewStat(discards)
+		c.Freshness = NewStat(freshness)
+		c.Feasible = c.Invalid == 0 && c.DiscardRate.Mean <= spec.MaxDiscardRate && c.Freshness.Mean >= spec.MinFreshness
+		candidates[i] = c
+	}
+	return candidates
+}
+
+// PrintPlan writes the cheapest candidates as a table, at most limit rows (all if limit < 1).
+func PrintPlan(w io.Writer, candidates []Candidate, limit int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COST\tCOOLERS\tHEATERS\tSHELVES\tDISCARD RATE\tFRESHNESS\tINVALID\tFEASIBLE")
+	for i, c := range candidates {
+		if limit > 0 && i >= limit {
+			break
+		}
+		cfg := c.Config
+		fmt.Fprintf(tw, "%.0f\t%dx%d\t%dx%d\t%dx%d\t%s\t%s\t%d\t%v\n", c.Cost,
+			cfg.NumCoolers, cfg.CoolerCap, cfg.NumHeaters, cfg.HeaterCap, cfg.NumShelves, cfg.ShelfCap,
+			c.DiscardRate, c.Freshness, c.Invalid, c.Feasible)
+	}
+	tw.Flush()
+}
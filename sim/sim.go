@@ -0,0 +1,135 @@
+package sim
+
+import (
+	"challenge/arrival"
+	"challenge/clock"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"challenge/report"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Epoch is the virtual time at which every simulation starts.
+var Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Scenario describes a simulated harness run.
+type Scenario struct {
+	Orders   []entity.Order
+	Rate     time.Duration // Time between order placements when Arrivals is nil.
+	Arrivals arrival.Model // Arrival process of the orders.
+	Min      time.Duration // Minimum pickup delay.
+	Max      time.Duration // Maximum pickup delay.
+	Seed     int64         // Seed for the arrivals and pickup delays.
+	Stays    []Stay        // Recorded arrival and pickup of each order, replacing the drawn ones if set.
+}
+
+// Stay is when an order arrives and is picked up, as offsets from the start of a run.
+type Stay struct {
+	Arrive time.Duration
+	Pickup time.Duration
+}
+
+// Visit is an order with the times it is placed and picked up in a scenario.
+type Visit struct {
+	Order    entity.Order
+	PlaceAt  time.Time
+	PickupAt time.Time
+}
+
+// Visits schedules the scenario: orders are placed from Epoch as the arrival model (every
+// Rate by default) dictates and picked up after a delay drawn uniformly from [Min, Max),
+// both derived from the scenario's seed but drawn from separate streams. Recorded stays,
+// when there is one per order, are used as they are.
+func (sc Scenario) Visits() []Visit {
+	if len(sc.Stays) > 0 && len(sc.Stays) == len(sc.Orders) {
+		visits := make([]Visit, 0, len(sc.Orders))
+		for i, o := range sc.Orders {
+			visits = append(visits, Visit{Order: o, PlaceAt: Epoch.Add(sc.Stays[i].Arrive), PickupAt: Epoch.Add(sc.Stays[i].Pickup)})
+		}
+		return visits
+	}
+	rng := rand.New(rand.NewSource(sc.Seed))
+	model := sc.Arrivals
+	if model == nil {
+		model = arrival.Constant{Interval: sc.Rate}
+	}
+	arrivals := model.Start(rand.New(rand.NewSource(arrival.Seed(sc.Seed))))
+	visits := make([]Visit, 0, len(sc.Orders))
+	for _, o := range sc.Orders {
+		placeAt := Epoch.Add(arrivals())
+		delay := sc.Min
+		if sc.Max > sc.Min {
+			delay += time.Duration(rng.Int63n(int64(sc.Max - sc.Min)))
+		}
+		visits = append(visits, Visit{Order: o, PlaceAt: placeAt, PickupAt: placeAt.Add(delay)})
+	}
+	return visits
+}
+
+// Result is the outcome of a simulated run.
+type Result struct {
+	Actions    []logic.Action
+	Layout     []report.StorageInfo
+	Summary    report.Summary
+	Violations []report.Violation
+}
+
+// Valid reports whether the offline validator accepted the run.
+func (r Result) Valid() bool {
+	return len(r.Violations) == 0
+}
+
+// Score is the freshness fraction delivered per order: every order contributes its
+// remaining freshness fraction at pickup, and discarded orders contribute nothing.
+// Invalid runs score zero.
+func (r Result) Score() float64 {
+	if !r.Valid() || r.Summary.Orders == 0 {
+		return 0
+	}
+	return r.Summary.Freshness.Mean * float64(r.Summary.Freshness.Count) / float64(r.Summary.Orders)
+}
+
+// harness returns the scenario's orders in the order they arrive, with the harness
+// options that play it: recorded stays become a trace of arrivals and fixed pickup delays.
+func (sc Scenario) harness() ([]entity.Order, logic.HarnessOptions) {
+	opts := logic.HarnessOptions{OrderInterval: sc.Rate, Arrivals: sc.Arrivals, MinPickup: sc.Min, MaxPickup: sc.Max, Seed: sc.Seed}
+	if len(sc.Stays) == 0 || len(sc.Stays) != len(sc.Orders) {
+		return sc.Orders, opts
+	}
+	index := make([]int, len(sc.Orders))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool { return sc.Stays[index[a]].Arrive < sc.Stays[index[b]].Arrive })
+	orders := make([]entity.Order, 0, len(index))
+	trace := arrival.Trace{}
+	for _, i := range index {
+		orders = append(orders, sc.Orders[i])
+		trace.Offsets = append(trace.Offsets, sc.Stays[i].Arrive)
+		opts.PickupDelays = append(opts.PickupDelays, sc.Stays[i].Pickup-sc.Stays[i].Arrive)
+	}
+	opts.Arrivals = trace
+	return orders, opts
+}
+
+// Run plays a scenario through the harness on a virtual clock, and validates and
+// summarizes the resulting action log. Runs are deterministic for a seed.
+func Run(cfg config.FulfillmentConfig, sc Scenario) Result {
+	clk := clock.NewVirtual(Epoch)
+	fs := logic.NewFulfillmentSystem(cfg, logic.WithClock(clk))
+	defer fs.Close()
+	orders, opts := sc.harness()
+	fs.StreamHarness(logic.Stream(orders), opts)
+
+	actions := fs.ActionLog()
+	layout := report.LayoutOf(fs)
+	return Result{
+		Actions:    actions,
+		Layout:     layout,
+		Summary:    report.Summarize(sc.Orders, actions, layout),
+		Violations: report.Validate(sc.Orders, actions, layout),
+	}
+}
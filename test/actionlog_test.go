@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	"challenge/actionlog"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestActionLogRoundTrip(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers: 0,
+		CoolerCap:  0,
+		NumHeaters: 1,
+		HeaterCap:  1,
+		NumShelves: 1,
+		ShelfCap:   2,
+	}
+	fs := logic.NewFulfillmentSystem(cfg)
+	fs.PlaceOrder(entity.Order{ID: "1", Temperature: config.TEMP_TYPE_HOT, Freshness: 10 * time.Second})
+	fs.PlaceOrder(entity.Order{ID: "2", Temperature: config.TEMP_TYPE_ROOM, Freshness: 20 * time.Second})
+	fs.PickupOrder("1")
+
+	if fs.Actions[0].Storage != "Heater-1" || fs.Actions[1].Storage != "Shelf-1" {
+		t.Fatalf("Actions were not enriched with storage names: %+v", fs.Actions)
+	}
+	if a := fs.Actions[2]; a.Temperature != config.TEMP_TYPE_HOT || a.Source != "Heater-1" || a.Target != "" || a.Rule != logic.RulePickup {
+		t.Fatalf("Pickup was not enriched with its temperature, source and rule: %+v", a)
+	}
+
+	for _, format := range []actionlog.Format{actionlog.FormatJSONL, actionlog.FormatCSV} {
+		var buf bytes.Buffer
+		if err := actionlog.Write(&buf, format, fs.Actions, time.Second, 2*time.Second, 3*time.Second); err != nil {
+			t.Fatalf("%s: write failed: %v", format, err)
+		}
+		loaded, err := actionlog.Read(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", format, err)
+		}
+		if len(loaded) != len(fs.Actions) {
+			t.Fatalf("%s: expected %d actions, got %d", format, len(fs.Actions), len(loaded))
+		}
+		for i, a := range loaded {
+			want := fs.Actions[i]
+			if a.Timestamp != want.Timestamp || a.OrderID != want.OrderID || a.Action != want.Action || a.Storage != want.Storage ||
+				a.Temperature != want.Temperature || a.Source != want.Source || a.Target != want.Target || a.Rule != want.Rule {
+				t.Errorf("%s: action %d mismatch: got %+v, want %+v", format, i, a, want)
+			}
+			if diff := a.Freshness - want.Freshness; diff > time.Millisecond || diff < -time.Millisecond {
+				t.Errorf("%s: action %d freshness mismatch: got %v, want %v", format, i, a.Freshness, want.Freshness)
+			}
+		}
+	}
+}
+
+func TestActionLogCSVHeader(t *testing.T) {
+	for _, header := range []string{
+		"timestamp,id,action,storage,freshness\n",
+		"timestamp,id,action,storage,freshness,operator,reason,temperature,source,target,rule,decay\n",
+	} {
+		if _, err := actionlog.ReadCSV(bytes.NewBufferString(header)); err == nil {
+			t.Errorf("Expected an error for the header %q", header)
+		}
+	}
+}
+
+func TestActionLogSolutionFormat(t *testing.T) {
+	actions := []logic.Action{
+		{Timestamp: 100, OrderID: "a", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1"},
+		{Timestamp: 200, OrderID: "a", Action: config.ACTION_TYPE_PICKUP, Storage: "Shelf-1"},
+	}
+	var buf bytes.Buffer
+	if err := actionlog.WriteSolution(&buf, actions, 500*time.Millisecond, 4*time.Second, 8*time.Second); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if _, ok := raw["options"]; !ok {
+		t.Errorf("Solution is missing options")
+	}
+
+	sol, err := actionlog.ReadSolution(&buf)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if rate, min, max := actionlog.SolutionOptions(sol); rate != 500*time.Millisecond || min != 4*time.Second || max != 8*time.Second {
+		t.Errorf("Unexpected options: %v %v %v", rate, min, max)
+	}
+	if len(sol.Actions) != 2 || sol.Actions[1].ID != "a" || sol.Actions[1].Action != config.ACTION_TYPE_PICKUP {
+		t.Errorf("Unexpected actions: %+v", sol.Actions)
+	}
+}
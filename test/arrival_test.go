@@ -0,0 +1,85 @@
+package test
+
+import (
+	"challenge/arrival"
+	"challenge/config"
+	"challenge/sim"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestArrivalModels(t *testing.T) {
+	constant := arrival.Constant{Interval: 500 * time.Millisecond}.Start(nil)
+	for i := 0; i < 3; i++ {
+		if at := constant(); at != time.Duration(i)*500*time.Millisecond {
+			t.Errorf("Constant arrival %d at %v", i, at)
+		}
+	}
+
+	const n = 20000
+	poisson := arrival.Poisson{Rate: 4}.Start(rand.New(rand.NewSource(1)))
+	var last time.Duration
+	for i := 0; i < n; i++ {
+		last = poisson()
+	}
+	if mean := last.Seconds() / n; math.Abs(mean-0.25) > 0.01 {
+		t.Errorf("Expected a mean Poisson interval near 0.25s, got %.3fs", mean)
+	}
+
+	burst := arrival.Burst{Rate: 1, BurstRate: 9, Every: time.Minute, Length: 15 * time.Second}
+	seq := burst.Start(rand.New(rand.NewSource(1)))
+	inBurst := 0
+	for i := 0; i < n; i++ {
+		if math.Mod(seq().Seconds(), 60) < 15 {
+			inBurst++
+		}
+	}
+	// A quarter of the time at 9/s against three quarters at 1/s: 75% of arrivals.
+	if frac := float64(inBurst) / n; math.Abs(frac-0.75) > 0.02 {
+		t.Errorf("Expected 75%% of arrivals during bursts, got %.1f%%", 100*frac)
+	}
+
+	curve := arrival.Curve{Points: []config.RatePoint{{Hour: 6, Rate: 1}, {Hour: 12, Rate: 4}}, StartHour: 9}
+	for _, tc := range []struct{ t, rate float64 }{{0, 2.5}, {3 * 3600, 4}, {15 * 3600, 2}, {21 * 3600, 1}} {
+		if r := curve.RateAt(tc.t); math.Abs(r-tc.rate) > 1e-9 {
+			t.Errorf("Expected curve rate %g at %gs, got %g", tc.rate, tc.t, r)
+		}
+	}
+
+	base := time.Unix(1000, 0)
+	trace := arrival.NewTrace([]time.Time{base.Add(4 * time.Second), base, base.Add(time.Second)}).Start(nil)
+	for _, want := range []time.Duration{0, time.Second, 4 * time.Second, 6 * time.Second, 8 * time.Second} {
+		if at := trace(); at != want {
+			t.Errorf("Expected trace arrival at %v, got %v", want, at)
+		}
+	}
+}
+
+func TestArrivalSpecDrivesSimulation(t *testing.T) {
+	spec, err := arrival.ParseSpec("burst:rate=0.5,burst_rate=5,burst_every_s=30,burst_length_s=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := arrival.FromConfig(spec, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := arrival.FromConfig(&config.ArrivalConfig{Model: arrival.ModelPoisson}, time.Second); err == nil {
+		t.Errorf("Expected an error for a Poisson model without a rate")
+	}
+
+	sc := sim.Scenario{Orders: simOrders(60), Arrivals: model, Min: 2 * time.Second, Max: 6 * time.Second, Seed: 3}
+	visits := sc.Visits()
+	for i := 1; i < len(visits); i++ {
+		if visits[i].PlaceAt.Before(visits[i-1].PlaceAt) {
+			t.Fatalf("Arrival %d at %v before arrival %d at %v", i, visits[i].PlaceAt, i-1, visits[i-1].PlaceAt)
+		}
+	}
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 4, NumHeaters: 1, HeaterCap: 4, NumShelves: 1, ShelfCap: 8}
+	first, second := sim.Run(cfg, sc), sim.Run(cfg, sc)
+	if !first.Valid() || first.Score() != second.Score() {
+		t.Errorf("Expected valid, deterministic runs, got scores %v and %v (%d violations)", first.Score(), second.Score(), len(first.Violations))
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"challenge/catalog"
+	css "challenge/client"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"challenge/report"
+	"challenge/workload"
+	"testing"
+	"time"
+)
+
+func TestMenuFillsDefaultsAndValidatesOrders(t *testing.T) {
+	menu := catalog.Catalog{Items: []catalog.Item{
+		{Name: "Pizza", Temp: config.TEMP_TYPE_HOT, FreshnessMin: 100, FreshnessMax: 200, Size: 2, Value: 12},
+		{Name: "Soda", Temp: config.TEMP_TYPE_COLD, Freshness: 300, Value: 2},
+	}}
+	if err := menu.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := workload.ToMenuOrder(css.Order{ID: "1", Name: "Pizza"}, menu)
+	if err != nil || order.Temperature != config.TEMP_TYPE_HOT || order.Freshness != 150*time.Second || order.Size != 2 || order.Value != 12 {
+		t.Errorf("Expected menu defaults, got %+v, error %v", order, err)
+	}
+	order, err = workload.ToMenuOrder(css.Order{ID: "2", Name: "Soda", Temp: config.TEMP_TYPE_ROOM, Freshness: 60}, menu)
+	if err == nil || order.Temperature != config.TEMP_TYPE_ROOM || order.Freshness != 60*time.Second {
+		t.Errorf("Expected a mismatch error keeping the order's values, got %+v, error %v", order, err)
+	}
+	if _, err := workload.ToMenuOrder(css.Order{ID: "3", Name: "Burger"}, menu); err == nil {
+		t.Errorf("Expected an error for an item missing from the menu")
+	}
+}
+
+func TestSummarizeWasteByMenuItem(t *testing.T) {
+	layout := []report.StorageInfo{{Name: "Shelf-1", Group: report.GroupShelf, Capacity: 3}}
+	orders := []entity.Order{
+		{ID: "1", Name: "Pizza", Temperature: config.TEMP_TYPE_ROOM, Freshness: 20 * time.Second, Value: 12},
+		{ID: "2", Name: "Pizza", Temperature: config.TEMP_TYPE_ROOM, Freshness: 20 * time.Second, Value: 12},
+		{ID: "3", Name: "Soda", Temperature: config.TEMP_TYPE_ROOM, Freshness: 20 * time.Second, Value: 2},
+	}
+	actions := []logic.Action{
+		{Timestamp: 0, OrderID: "1", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1"},
+		{Timestamp: 1, OrderID: "2", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1"},
+		{Timestamp: 2, OrderID: "3", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1"},
+		{Timestamp: 3, OrderID: "1", Action: config.ACTION_TYPE_DISCARD, Storage: "Shelf-1"},
+		{Timestamp: 4, OrderID: "2", Action: config.ACTION_TYPE_PICKUP, Storage: "Shelf-1", Freshness: 10 * time.Second},
+		{Timestamp: 5, OrderID: "3", Action: config.ACTION_TYPE_PICKUP, Storage: "Shelf-1", Freshness: 10 * time.Second},
+	}
+
+	items := report.Summarize(orders, actions, layout).Items
+	if len(items) != 2 || items[0].Name != "Pizza" || items[0].Discarded != 1 || items[0].DiscardRate != 0.5 || items[0].WastedValue != 12 {
+		t.Errorf("Unexpected item stats: %+v", items)
+	}
+	if items[1].Name != "Soda" || items[1].PickedUp != 1 || items[1].WastedValue != 0 {
+		t.Errorf("Unexpected item stats: %+v", items)
+	}
+}
@@ -0,0 +1,32 @@
+package test
+
+import (
+	"challenge/clock"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"testing"
+	"time"
+)
+
+// newSystem starts a fulfillment system on a virtual clock, closed when the test ends.
+func newSystem(t *testing.T, cfg config.FulfillmentConfig) (*logic.FulfillmentSystem, *clock.Virtual) {
+	t.Helper()
+	clk := clock.NewVirtual(time.Unix(1000, 0))
+	fs := logic.NewFulfillmentSystem(cfg, logic.WithClock(clk))
+	t.Cleanup(fs.Close)
+	return fs, clk
+}
+
+// order returns a fresh order of the given temperature.
+func order(id, temp string, freshness time.Duration) entity.Order {
+	return entity.Order{ID: id, Temperature: temp, Freshness: freshness, InitialFreshness: freshness}
+}
+
+// checkInvariants fails the test if the system breaks any of its invariants.
+func checkInvariants(t *testing.T, fs *logic.FulfillmentSystem) {
+	t.Helper()
+	if violations := fs.CheckInvariants(); len(violations) != 0 {
+		t.Errorf("Unexpected invariant violations: %v", violations)
+	}
+}
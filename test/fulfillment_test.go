@@ -4,6 +4,9 @@ import (
 	"challenge/config"
 	"challenge/entity"
 	"challenge/logic"
+	"challenge/report"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -136,3 +139,46 @@ func TestDiscardHybridOrderFromShelfGroup(t *testing.T) {
 		t.Errorf("Order 3 should have been placed on the shelf")
 	}
 }
+
+func TestConcurrentOperationsProduceConsistentLog(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers: 1,
+		CoolerCap:  2,
+		NumHeaters: 1,
+		HeaterCap:  2,
+		NumShelves: 1,
+		ShelfCap:   3,
+	}
+	fs := logic.NewFulfillmentSystem(cfg)
+	defer fs.Close()
+
+	temps := []string{config.TEMP_TYPE_HOT, config.TEMP_TYPE_COLD, config.TEMP_TYPE_ROOM}
+	var orders []entity.Order
+	for i := 0; i < 200; i++ {
+		orders = append(orders, entity.Order{ID: fmt.Sprintf("%d", i), Temperature: temps[i%3], Freshness: time.Minute})
+	}
+
+	// Place, reallocate and pick up from many goroutines at once.
+	var wg sync.WaitGroup
+	for _, o := range orders {
+		wg.Add(1)
+		go func(o entity.Order) {
+			defer wg.Done()
+			fs.PlaceOrder(o)
+			fs.Reallocate()
+			fs.PickupOrder(o.ID)
+		}(o)
+	}
+	wg.Wait()
+
+	actions := fs.ActionLog()
+	for i := 1; i < len(actions); i++ {
+		if actions[i].Timestamp < actions[i-1].Timestamp {
+			t.Fatalf("Action log is not totally ordered at %d: %+v after %+v", i, actions[i], actions[i-1])
+		}
+	}
+	for _, v := range report.Validate(orders, actions, report.LayoutOf(fs)) {
+		// Any violation means operations on the same order interleaved.
+		t.Errorf("Inconsistent action log: %v", v)
+	}
+}
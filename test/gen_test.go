@@ -0,0 +1,81 @@
+package test
+
+import (
+	"bytes"
+	"challenge/catalog"
+	"challenge/config"
+	"challenge/sim"
+	"challenge/workload"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerateWorkload(t *testing.T) {
+	mix, err := workload.ParseMix("hot=0.5,cold=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := workload.GenSpec{
+		Count:      2000,
+		Seed:       7,
+		Catalog:    catalog.Default(),
+		Mix:        mix,
+		Timestamps: true,
+		Start:      time.Unix(1000, 0),
+		Rate:       time.Second,
+		MinPickup:  2 * time.Second,
+		MaxPickup:  4 * time.Second,
+	}
+	orders, err := workload.Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := workload.Generate(spec)
+	if !reflect.DeepEqual(orders, again) {
+		t.Errorf("Expected the same orders for the same seed")
+	}
+
+	temps := make(map[string]int)
+	ids := make(map[string]bool)
+	for i, o := range orders {
+		temps[o.Temp]++
+		ids[o.ID] = true
+		item, ok := spec.Catalog.Item(o.Name)
+		if !ok || item.Temp != o.Temp || o.Freshness < item.FreshnessMin || o.Freshness > item.FreshnessMax {
+			t.Fatalf("Order %+v does not match its catalog item %+v", o, item)
+		}
+		if o.ArriveAt != time.Unix(1000+int64(i), 0).UnixMicro() || o.PickupAt-o.ArriveAt < 2e6 || o.PickupAt-o.ArriveAt >= 4e6 {
+			t.Fatalf("Unexpected timestamps for order %d: %+v", i, o)
+		}
+	}
+	if len(ids) != len(orders) {
+		t.Errorf("Expected unique IDs, got %d for %d orders", len(ids), len(orders))
+	}
+	if temps[config.TEMP_TYPE_ROOM] != 0 || temps[config.TEMP_TYPE_HOT] < 900 || temps[config.TEMP_TYPE_HOT] > 1100 {
+		t.Errorf("Unexpected temperature mix: %v", temps)
+	}
+
+	var buf bytes.Buffer
+	if err := workload.WriteJSONL(&buf, orders); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := workload.Parse(buf.Bytes())
+	if err != nil || len(loaded) != len(orders) || loaded[0] != orders[0].Order {
+		t.Errorf("Expected generated orders to load back, got %d orders, error %v", len(loaded), err)
+	}
+	timed, err := workload.ParseGenerated(buf.Bytes())
+	if err != nil || len(timed) != len(orders) || timed[1] != orders[1] || !timed[1].Timed() {
+		t.Errorf("Expected generated orders to load back with their times, got %d orders, error %v", len(timed), err)
+	}
+
+	// Recorded stays replace the drawn arrivals and pickups.
+	sc := sim.Scenario{
+		Orders: workload.ToOrders(loaded[:2]),
+		Rate:   time.Second,
+		Stays:  []sim.Stay{{Arrive: 0, Pickup: 3 * time.Second}, {Arrive: 5 * time.Second, Pickup: 6 * time.Second}},
+	}
+	if v := sc.Visits(); !v[1].PlaceAt.Equal(sim.Epoch.Add(5*time.Second)) || !v[1].PickupAt.Equal(sim.Epoch.Add(6*time.Second)) {
+		t.Errorf("Expected the recorded stays to be replayed, got %+v", v)
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"challenge/arrival"
+	"challenge/config"
+	"challenge/logic"
+	"challenge/report"
+	"challenge/sim"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStreamHarnessAppliesBackPressure(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers: 1,
+		CoolerCap:  6,
+		NumHeaters: 1,
+		HeaterCap:  6,
+		NumShelves: 1,
+		ShelfCap:   12,
+	}
+	fs := logic.NewFulfillmentSystem(cfg)
+	defer fs.Close()
+
+	orders := simOrders(2000)
+	stats := fs.StreamHarness(logic.Stream(orders), logic.HarnessOptions{
+		MinPickup:  time.Millisecond,
+		MaxPickup:  3 * time.Millisecond,
+		Workers:    8,
+		MaxPending: 20,
+		Seed:       1,
+	})
+
+	if stats.Placed != len(orders) || stats.PickedUp != len(orders) {
+		t.Errorf("Expected every order placed and picked up, got %+v", stats)
+	}
+	if stats.PeakPending > 20 {
+		t.Errorf("Expected at most 20 pending pickups, got %d", stats.PeakPending)
+	}
+	if violations := report.Validate(orders, fs.ActionLog(), report.LayoutOf(fs)); len(violations) != 0 {
+		t.Errorf("Expected a valid action log, got %d violations, first: %v", len(violations), violations[0])
+	}
+}
+
+func TestStreamHarnessOnVirtualClock(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 6, NumHeaters: 1, HeaterCap: 6, NumShelves: 1, ShelfCap: 12}
+	orders := simOrders(60)
+	sc := sim.Scenario{Orders: orders, Arrivals: arrival.Poisson{Rate: 2}, Min: 2 * time.Second, Max: 6 * time.Second, Seed: 3}
+
+	// Placements follow the scenario's schedule to the microsecond, and the run takes no
+	// wall-clock time waiting for it.
+	fs, clk := newSystem(t, cfg)
+	clk.Set(sim.Epoch)
+	began := time.Now()
+	stats := fs.StreamHarness(logic.Stream(orders), logic.HarnessOptions{Arrivals: sc.Arrivals, MinPickup: sc.Min, MaxPickup: sc.Max, Seed: sc.Seed})
+	if elapsed := time.Since(began); elapsed > 5*time.Second {
+		t.Errorf("Expected the virtual run to finish quickly, took %v", elapsed)
+	}
+	if stats.Placed != len(orders) || stats.PickedUp != len(orders) {
+		t.Errorf("Expected every order placed and picked up, got %+v", stats)
+	}
+	placed := make(map[string]int64)
+	for _, a := range fs.ActionLog() {
+		if a.Action == config.ACTION_TYPE_PLACE {
+			placed[a.OrderID] = a.Timestamp
+		}
+	}
+	for _, v := range sc.Visits() {
+		if placed[v.Order.ID] != v.PlaceAt.UnixMicro() {
+			t.Errorf("Order %s: expected placement at %v, got %v", v.Order.ID, v.PlaceAt, time.UnixMicro(placed[v.Order.ID]).UTC())
+		}
+	}
+	if result := sim.Run(cfg, sc); !reflect.DeepEqual(result.Actions, fs.ActionLog()) {
+		t.Errorf("Expected sim.Run to play the same run as the harness")
+	}
+
+	// Intake pauses while MaxPending orders await pickup, on virtual time too.
+	fs, _ = newSystem(t, cfg)
+	stats = fs.StreamHarness(logic.Stream(orders), logic.HarnessOptions{Arrivals: sc.Arrivals, MinPickup: sc.Min, MaxPickup: sc.Max, Seed: sc.Seed, MaxPending: 5})
+	if stats.Placed != len(orders) || stats.PeakPending > 5 {
+		t.Errorf("Expected every order placed with at most 5 pending, got %+v", stats)
+	}
+	checkInvariants(t, fs)
+}
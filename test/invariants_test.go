@@ -0,0 +1,56 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"testing"
+	"time"
+)
+
+func TestInvariantsHoldAfterEveryOperation(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers:      1,
+		CoolerCap:       2,
+		NumHeaters:      1,
+		HeaterCap:       2,
+		NumShelves:      1,
+		ShelfCap:        3,
+		DebugInvariants: true,
+		InvariantMode:   logic.InvariantsPanic,
+	}
+	fs, clk := newSystem(t, cfg)
+
+	temps := []string{config.TEMP_TYPE_HOT, config.TEMP_TYPE_COLD, config.TEMP_TYPE_ROOM}
+	for i := 0; i < 12; i++ {
+		id := string(rune('a' + i))
+		fs.PlaceOrder(order(id, temps[i%3], time.Duration(10+i)*time.Second))
+		clk.Advance(time.Second)
+		if i%4 == 3 {
+			fs.PickupOrder(string(rune('a' + i - 2)))
+			fs.Reallocate()
+		}
+	}
+	checkInvariants(t, fs)
+}
+
+func TestInvariantsDetectCorruptedStorage(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 1, NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 1}
+	fs := logic.NewFulfillmentSystem(cfg)
+	fs.PlaceOrder(entity.Order{ID: "1", Temperature: config.TEMP_TYPE_HOT, Freshness: 20 * time.Second})
+	fs.PlaceOrder(entity.Order{ID: "2", Temperature: config.TEMP_TYPE_ROOM, Freshness: 20 * time.Second})
+	fs.Close()
+
+	// Tamper with the storages behind the closed system's back.
+	heater, shelf := fs.HeaterGroup.Storages[0], fs.ShelfGroup.Storages[0]
+	shelf.Orders["1"] = heater.Orders["1"]
+	delete(heater.Orders, "1")
+
+	rules := make(map[string]int)
+	for _, v := range fs.CheckInvariants() {
+		rules[v.Rule]++
+	}
+	if rules["capacity"] != 1 || rules["order-location"] != 1 || rules["log-consistency"] != 1 {
+		t.Errorf("Unexpected violations by rule: %v", rules)
+	}
+}
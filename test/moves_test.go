@@ -0,0 +1,85 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"testing"
+	"time"
+)
+
+func TestMoveLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		cfg   config.FulfillmentConfig
+		after time.Duration // Wait before order 1 may move back.
+	}{
+		{"max moves", config.FulfillmentConfig{MaxMovesPerOrder: 1}, 0},
+		{"min dwell", config.FulfillmentConfig{MinDwellMs: 10000}, 11 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.NumHeaters, cfg.HeaterCap, cfg.NumShelves, cfg.ShelfCap = 2, 1, 1, 2
+			fs, clk := newSystem(t, cfg)
+
+			fs.PlaceOrder(order("1", config.TEMP_TYPE_HOT, 30*time.Second))
+			fs.PlaceOrder(order("2", config.TEMP_TYPE_HOT, time.Minute))
+			fs.PlaceOrder(order("3", config.TEMP_TYPE_HOT, time.Minute))
+			// The outage forces order 1 onto the shelf, which counts as a move.
+			if err := fs.SetUnitStatus("Heater-1", entity.StatusOffline); err != nil {
+				t.Fatalf("SetUnitStatus: %v", err)
+			}
+			if o, where, _ := fs.Snapshot().Find("1"); where != "Shelf-1" || o.Moves != 1 {
+				t.Fatalf("Expected order 1 moved once to the shelf, got %q %+v", where, o)
+			}
+			// Order 1 is the most urgent, but may not move back yet: order 3 takes the heater.
+			if err := fs.SetUnitStatus("Heater-1", entity.StatusOnline); err != nil {
+				t.Fatalf("SetUnitStatus: %v", err)
+			}
+			snap := fs.Snapshot()
+			if _, where, _ := snap.Find("1"); where != "Shelf-1" {
+				t.Errorf("Expected order 1 to stay on the shelf, got %q", where)
+			}
+			if _, where, _ := snap.Find("3"); where != "Heater-1" {
+				t.Errorf("Expected order 3 in Heater-1, got %q", where)
+			}
+
+			clk.Advance(tc.after)
+			fs.PickupOrder("3")
+			_, where, _ := fs.Snapshot().Find("1")
+			if moved := where == "Heater-1"; moved != (tc.after > 0) {
+				t.Errorf("Unexpected location of order 1 after the dwell time: %q", where)
+			}
+			checkInvariants(t, fs)
+		})
+	}
+}
+
+func TestMoveCost(t *testing.T) {
+	// The gain comes from the decay rates the storages apply: with equal rates a refill
+	// gains nothing, and a shelf with a 2x decay multiplier makes moving to the heater
+	// double the order's remaining lifetime, a gain of 1x its current lifetime.
+	for _, tc := range []struct {
+		shelfDecay float64
+		cost       float64
+		moves      bool
+	}{
+		{0, 0, true},
+		{0, 0.5, false},
+		{2, 0.5, true},
+		{2, 1.5, false},
+	} {
+		cfg := config.FulfillmentConfig{
+			NumHeaters: 1, HeaterCap: 1,
+			Shelves:        []config.UnitConfig{{Capacity: 2, DecayMultiplier: tc.shelfDecay}},
+			MoveCostFactor: tc.cost,
+		}
+		fs, _ := newSystem(t, cfg)
+		for _, id := range []string{"1", "2"} {
+			fs.PlaceOrder(order(id, config.TEMP_TYPE_HOT, time.Minute))
+		}
+		fs.PickupOrder("1")
+		if _, where, _ := fs.Snapshot().Find("2"); (where == "Heater-1") != tc.moves {
+			t.Errorf("Shelf decay %g, cost %g: unexpected location of order 2: %q", tc.shelfDecay, tc.cost, where)
+		}
+	}
+}
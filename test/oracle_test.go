@@ -0,0 +1,57 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/oracle"
+	"challenge/report"
+	"challenge/sim"
+	"testing"
+	"time"
+)
+
+func TestOracleNeverWastesMoreThanOnlinePolicy(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 1, NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 2}
+	for _, n := range []int{12, 80} {
+		sc := sim.Scenario{Orders: simOrders(n), Rate: 200 * time.Millisecond, Min: 2 * time.Second, Max: 4 * time.Second, Seed: 3}
+		online := sim.Run(cfg, sc)
+
+		sol := oracle.Solve(oracle.Problem{Visits: sc.Visits(), Layout: online.Layout, Objective: oracle.ObjectiveWaste}, oracle.DefaultOptions())
+		if sol.Exact != (n <= oracle.DefaultOptions().ExactLimit) {
+			t.Errorf("%d orders: unexpected exact flag %v", n, sol.Exact)
+		}
+		if violations := report.Validate(sc.Orders, sol.Actions, online.Layout); len(violations) > 0 {
+			t.Fatalf("%d orders: oracle plan is invalid: %v", n, violations)
+		}
+		summary := report.Summarize(sc.Orders, sol.Actions, online.Layout)
+		if summary.Actions[config.ACTION_TYPE_DISCARD] != sol.Discarded {
+			t.Errorf("%d orders: solution reports %d discards, log has %d", n, sol.Discarded, summary.Actions[config.ACTION_TYPE_DISCARD])
+		}
+		if sol.Discarded > online.Summary.Actions[config.ACTION_TYPE_DISCARD] {
+			t.Errorf("%d orders: oracle discarded %d, online policy only %d", n, sol.Discarded, online.Summary.Actions[config.ACTION_TYPE_DISCARD])
+		}
+	}
+}
+
+func TestOracleExactPrefersEvictingTheLongestStay(t *testing.T) {
+	layout := []report.StorageInfo{{Name: "Shelf-1", Group: report.GroupShelf, Capacity: 1}}
+	visits := []sim.Visit{
+		// A long stay placed first, then two short stays that only fit if it is discarded.
+		{Order: simOrders(1)[0], PlaceAt: sim.Epoch, PickupAt: sim.Epoch.Add(9 * time.Second)},
+	}
+	visits[0].Order.Temperature = config.TEMP_TYPE_ROOM
+	for i, at := range []time.Duration{time.Second, 3 * time.Second} {
+		o := simOrders(3)[i+1]
+		o.Temperature = config.TEMP_TYPE_ROOM
+		visits = append(visits, sim.Visit{Order: o, PlaceAt: sim.Epoch.Add(at), PickupAt: sim.Epoch.Add(at + time.Second)})
+	}
+
+	sol := oracle.Solve(oracle.Problem{Visits: visits, Layout: layout, Objective: oracle.ObjectiveWaste}, oracle.DefaultOptions())
+	if !sol.Exact || sol.Discarded != 1 {
+		t.Fatalf("Expected an exact plan with one discard, got exact=%v discarded=%d", sol.Exact, sol.Discarded)
+	}
+	for _, a := range sol.Actions {
+		if a.Action == config.ACTION_TYPE_DISCARD && a.OrderID != visits[0].Order.ID {
+			t.Errorf("Expected order %s to be discarded, got %s", visits[0].Order.ID, a.OrderID)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnitOutage(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 2, HeaterCap: 2, NumShelves: 1, ShelfCap: 2}
+	fs, _ := newSystem(t, cfg)
+
+	// Heater-1 holds 1 and 2, Heater-2 holds 3.
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_HOT, 60*time.Second))
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_HOT, 30*time.Second))
+	fs.PlaceOrder(order("3", config.TEMP_TYPE_HOT, 90*time.Second))
+
+	// The most urgent order takes the free heater slot, the other goes to the shelf.
+	if err := fs.SetUnitStatus("Heater-1", entity.StatusOffline); err != nil {
+		t.Fatalf("SetUnitStatus: %v", err)
+	}
+	snap := fs.Snapshot()
+	if _, where, _ := snap.Find("2"); where != "Heater-2" {
+		t.Errorf("Expected order 2 in Heater-2, got %q", where)
+	}
+	if _, where, _ := snap.Find("1"); where != "Shelf-1" {
+		t.Errorf("Expected order 1 on Shelf-1, got %q", where)
+	}
+	if s, _ := snap.Storage("Heater-1"); s.Status != entity.StatusOffline || len(s.Orders) != 0 {
+		t.Errorf("Expected Heater-1 offline and empty, got %+v", s)
+	}
+
+	// New orders skip the offline unit.
+	fs.PlaceOrder(order("4", config.TEMP_TYPE_ROOM, time.Minute))
+	fs.PlaceOrder(order("5", config.TEMP_TYPE_HOT, time.Minute))
+	if _, where, _ := fs.Snapshot().Find("5"); where != "Shelf-1" {
+		t.Errorf("Expected order 5 on the shelf, got %q", where)
+	}
+
+	// Placing 5 discarded one shelf order; with the shelf full, taking Heater-2 offline
+	// discards by policy to make room for its two orders.
+	if err := fs.SetUnitStatus("Heater-2", entity.StatusOffline); err != nil {
+		t.Fatalf("SetUnitStatus: %v", err)
+	}
+	discards := 0
+	for _, a := range fs.ActionLog() {
+		if a.Action == config.ACTION_TYPE_DISCARD {
+			discards++
+		}
+	}
+	if discards != 3 {
+		t.Errorf("Expected 3 discards in all, got %d", discards)
+	}
+
+	// Back online, Heater-1 takes the hot orders back from the shelf.
+	if err := fs.SetUnitStatus("Heater-1", entity.StatusOnline); err != nil {
+		t.Fatalf("SetUnitStatus: %v", err)
+	}
+	for _, s := range fs.Snapshot().Storages {
+		if s.Group == logic.GroupShelf {
+			for _, o := range s.Orders {
+				if o.Temperature == config.TEMP_TYPE_HOT {
+					t.Errorf("Expected hot order %s back in Heater-1", o.ID)
+				}
+			}
+		}
+	}
+	checkInvariants(t, fs)
+
+	if err := fs.SetUnitStatus("Freezer-9", entity.StatusOffline); err == nil {
+		t.Errorf("Expected an error for an unknown unit")
+	}
+}
+
+func TestDegradedUnitDrains(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 2, CoolerCap: 1, NumShelves: 1, ShelfCap: 0}
+	fs, _ := newSystem(t, cfg)
+
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_COLD, time.Minute))
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_COLD, time.Minute))
+
+	// Nowhere to go: the order stays in the degraded cooler.
+	if _, err := fs.ExecAdmin("degraded Cooler-1"); err != nil {
+		t.Fatalf("ExecAdmin: %v", err)
+	}
+	if _, where, _ := fs.Snapshot().Find("1"); where != "Cooler-1" {
+		t.Errorf("Expected order 1 to stay in Cooler-1, got %q", where)
+	}
+
+	// Once Cooler-2 frees up, the order moves there without waiting for a reallocation.
+	fs.PickupOrder("2")
+	if _, where, _ := fs.Snapshot().Find("1"); where != "Cooler-2" {
+		t.Errorf("Expected order 1 moved to Cooler-2, got %q", where)
+	}
+	out, err := fs.ExecAdmin("status")
+	if err != nil || !strings.Contains(out, "Cooler-1") || !strings.Contains(out, entity.StatusDegraded) {
+		t.Errorf("Unexpected status output %q, %v", out, err)
+	}
+	if _, err := fs.ExecAdmin("reboot Cooler-1"); err == nil {
+		t.Errorf("Expected an error for an unknown command")
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"bytes"
+	"challenge/actionlog"
+	"challenge/config"
+	"challenge/logic"
+	"testing"
+	"time"
+)
+
+func TestOperatorOverrides(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 1, NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 2}
+	fs, _ := newSystem(t, cfg)
+
+	// The cooler holds 1; 2 overflows to the shelf and is pinned there.
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_COLD, time.Minute))
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_COLD, time.Minute))
+	if err := fs.PinOrder("2", "alice"); err != nil {
+		t.Fatalf("PinOrder: %v", err)
+	}
+
+	// A full shelf discards the unpinned order, and the pinned one stays when the cooler frees up.
+	fs.PlaceOrder(order("3", config.TEMP_TYPE_ROOM, time.Minute))
+	fs.PlaceOrder(order("4", config.TEMP_TYPE_ROOM, time.Minute))
+	fs.PickupOrder("1")
+	snap := fs.Snapshot()
+	if o, where, _ := snap.Find("2"); where != "Shelf-1" || !o.Pinned {
+		t.Errorf("Expected order 2 pinned on Shelf-1, got %q %+v", where, o)
+	}
+	if _, _, ok := snap.Find("3"); ok {
+		t.Errorf("Expected order 3 discarded")
+	}
+
+	// Manual moves and discards are logged with the operator and reason.
+	if err := fs.MoveOrder("2", "Freezer-1", "alice", ""); err == nil {
+		t.Errorf("Expected a move to an unknown unit to fail")
+	}
+	if _, err := fs.ExecAdminAs("bob", "move 2 Cooler-1 back to cold"); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if err := fs.DiscardOrder("4", "bob", ""); err == nil {
+		t.Errorf("Expected a discard without a reason to fail")
+	}
+	if _, err := fs.ExecAdminAs("bob", "discard 4 dropped on the floor"); err != nil {
+		t.Fatalf("discard: %v", err)
+	}
+	var overrides []logic.Action
+	for _, a := range fs.ActionLog() {
+		if a.Operator != "" {
+			overrides = append(overrides, a)
+		}
+	}
+	if len(overrides) != 3 {
+		t.Fatalf("Expected pin, move and discard overrides, got %+v", overrides)
+	}
+	if a := overrides[1]; a.Action != config.ACTION_TYPE_MOVE || a.Storage != "Cooler-1" || a.Operator != "bob" || a.Rule != logic.RuleOverride || a.Reason != "back to cold" {
+		t.Errorf("Unexpected move override %+v", a)
+	}
+	if a := overrides[2]; a.Action != config.ACTION_TYPE_DISCARD || a.Operator != "bob" || a.Reason != "dropped on the floor" {
+		t.Errorf("Unexpected discard override %+v", a)
+	}
+	checkInvariants(t, fs)
+
+	// Pins stay out of the submission but survive an export round trip.
+	for _, a := range actionlog.ToClientActions(fs.ActionLog()) {
+		if a.Action == config.ACTION_TYPE_PIN {
+			t.Errorf("Expected pins left out of the client actions, got %+v", a)
+		}
+	}
+	var buf bytes.Buffer
+	if err := actionlog.WriteCSV(&buf, fs.ActionLog()); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	read, err := actionlog.ReadCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if a := read[len(read)-1]; a.Operator != "bob" || a.Reason != "dropped on the floor" {
+		t.Errorf("Expected the operator and reason read back, got %+v", a)
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestShelfQuotas(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumShelves: 1, ShelfCap: 5,
+		ShelfQuotas: map[string]config.QuotaConfig{
+			config.TEMP_TYPE_HOT:  {Max: 3},
+			config.TEMP_TYPE_COLD: {Reserved: 2},
+		},
+	}
+	fs, _ := newSystem(t, cfg)
+
+	// A hot rush: the fourth hot order replaces the least fresh hot one rather than
+	// taking the slots kept for cold orders.
+	for i := 1; i <= 4; i++ {
+		fs.PlaceOrder(order(fmt.Sprintf("h%d", i), config.TEMP_TYPE_HOT, time.Duration(i)*time.Minute))
+	}
+	shelf, _ := fs.Snapshot().Storage("Shelf-1")
+	if shelf.Used != 3 {
+		t.Errorf("Expected 3 hot orders on the shelf, got %d slots used", shelf.Used)
+	}
+	if _, _, ok := fs.Snapshot().Find("h1"); ok {
+		t.Errorf("Expected h1 discarded to keep hot orders within their quota")
+	}
+
+	// Room orders cannot take the cold reservation either: they displace hot orders.
+	fs.PlaceOrder(order("r1", config.TEMP_TYPE_ROOM, 10*time.Minute))
+	fs.PlaceOrder(order("r2", config.TEMP_TYPE_ROOM, 10*time.Minute))
+	for _, id := range []string{"h2", "h3"} {
+		if _, _, ok := fs.Snapshot().Find(id); ok {
+			t.Errorf("Expected %s discarded to make room outside the cold reservation", id)
+		}
+	}
+
+	// Cold orders still find room.
+	fs.PlaceOrder(order("c1", config.TEMP_TYPE_COLD, time.Minute))
+	fs.PlaceOrder(order("c2", config.TEMP_TYPE_COLD, time.Minute))
+	shelf, _ = fs.Snapshot().Storage("Shelf-1")
+	if shelf.Used != 5 {
+		t.Errorf("Expected a full shelf, got %d slots used", shelf.Used)
+	}
+	want := []logic.QuotaSnapshot{
+		{Temperature: config.TEMP_TYPE_COLD, Reserved: 2, Used: 2},
+		{Temperature: config.TEMP_TYPE_HOT, Max: 3, Used: 1},
+	}
+	if fmt.Sprint(shelf.Quotas) != fmt.Sprint(want) {
+		t.Errorf("Expected quota usage %v, got %v", want, shelf.Quotas)
+	}
+	checkInvariants(t, fs)
+}
+
+func TestQuotaFits(t *testing.T) {
+	s := entity.NewStorage("Shelf-1", 4)
+	s.Quotas = map[string]entity.Quota{config.TEMP_TYPE_COLD: {Reserved: 2}}
+	room := entity.Order{ID: "r", Temperature: config.TEMP_TYPE_ROOM}
+	cold := entity.Order{ID: "c", Temperature: config.TEMP_TYPE_COLD}
+	if !s.Add(&entity.StoredOrder{Order: room}) {
+		t.Fatalf("Expected room for one room order")
+	}
+	room.ID = "r2"
+	if !s.Add(&entity.StoredOrder{Order: room}) {
+		t.Fatalf("Expected room for a second room order")
+	}
+	room.ID = "r3"
+	if s.Fits(room) {
+		t.Errorf("Expected the cold reservation to keep a third room order out")
+	}
+	if s.Helps(cold, room) || !s.Helps(entity.Order{Temperature: config.TEMP_TYPE_ROOM}, room) {
+		t.Errorf("Expected only removing a room order to help a room order")
+	}
+	if !s.Fits(cold) || !s.CanHold(room) {
+		t.Errorf("Expected cold orders to fit and room orders to fit once emptied")
+	}
+}
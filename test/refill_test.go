@@ -0,0 +1,53 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/logic"
+	"testing"
+	"time"
+)
+
+func TestFreedIdealSpaceIsRefilledByPriority(t *testing.T) {
+	for _, tc := range []struct {
+		priority string
+		rescued  string
+	}{
+		{logic.RescueMostUrgent, "3"},
+		{logic.RescueOldest, "2"},
+	} {
+		t.Run(tc.priority, func(t *testing.T) {
+			cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 3, RescuePriority: tc.priority}
+			fs, clk := newSystem(t, cfg)
+
+			fs.PlaceOrder(order("1", config.TEMP_TYPE_HOT, 60*time.Second))
+			fs.PlaceOrder(order("2", config.TEMP_TYPE_HOT, 40*time.Second))
+			clk.Advance(time.Second)
+			fs.PlaceOrder(order("3", config.TEMP_TYPE_HOT, 20*time.Second))
+			clk.Advance(time.Second)
+			fs.PickupOrder("1")
+
+			actions := fs.ActionLog()
+			last := actions[len(actions)-1]
+			if last.Action != config.ACTION_TYPE_MOVE || last.OrderID != tc.rescued || last.Storage != "Heater-1" {
+				t.Errorf("Expected order %s moved to the heater right after the pickup, got %+v", tc.rescued, last)
+			}
+		})
+	}
+}
+
+func TestCancelOrderDiscardsAndRefills(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 3}
+	fs, _ := newSystem(t, cfg)
+
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_HOT, 60*time.Second))
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_HOT, 60*time.Second))
+	fs.CancelOrder("1")
+
+	actions := fs.ActionLog()
+	if len(actions) != 4 || actions[2].Action != config.ACTION_TYPE_DISCARD || actions[2].OrderID != "1" {
+		t.Fatalf("Expected order 1 discarded on cancellation, got %+v", actions)
+	}
+	if actions[3].Action != config.ACTION_TYPE_MOVE || actions[3].OrderID != "2" {
+		t.Errorf("Expected order 2 moved into the freed heater, got %+v", actions[3])
+	}
+}
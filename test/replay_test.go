@@ -0,0 +1,136 @@
+package test
+
+import (
+	"challenge/catalog"
+	css "challenge/client"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/report"
+	"challenge/workload"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 2}
+	fs, clk := newSystem(t, cfg)
+	orders := []entity.Order{
+		{ID: "1", Name: "Soup", Temperature: config.TEMP_TYPE_HOT, Freshness: time.Minute, InitialFreshness: time.Minute},
+		{ID: "2", Name: "Pie", Temperature: config.TEMP_TYPE_HOT, Freshness: time.Minute, InitialFreshness: time.Minute},
+		{ID: "3", Name: "Bread", Temperature: config.TEMP_TYPE_ROOM, Freshness: time.Minute, InitialFreshness: time.Minute},
+	}
+	// place 1, place 2 at +10s, pickup 1 and move 2 to the heater at +15s, place 3 at +20s.
+	fs.PlaceOrder(orders[0])
+	clk.Advance(10 * time.Second)
+	fs.PlaceOrder(orders[1])
+	clk.Advance(5 * time.Second)
+	fs.PickupOrder("1")
+	clk.Advance(5 * time.Second)
+	fs.PlaceOrder(orders[2])
+	layout := report.LayoutOf(fs)
+
+	replay := report.NewReplay(layout, orders, fs.ActionLog())
+	if replay.Len() != 5 || replay.Pos() != 0 {
+		t.Fatalf("Expected 5 actions to replay from the start, got %d at %d", replay.Len(), replay.Pos())
+	}
+	contents := func() map[string][]report.OrderView {
+		got := make(map[string][]report.OrderView)
+		for _, v := range replay.View() {
+			got[v.Name] = v.Orders
+		}
+		return got
+	}
+
+	// Between the second place and the pickup, freshness keeps decaying.
+	replay.SeekTime(replay.Start().Add(12 * time.Second))
+	got := contents()
+	if replay.Pos() != 2 || len(got["Heater-1"]) != 1 || got["Heater-1"][0].Freshness != 18*time.Second {
+		t.Errorf("Unexpected heater at +12s: %+v", got["Heater-1"])
+	}
+	if len(got["Shelf-1"]) != 1 || got["Shelf-1"][0].ID != "2" || got["Shelf-1"][0].Name != "Pie" || got["Shelf-1"][0].Freshness != 28*time.Second {
+		t.Errorf("Unexpected shelf at +12s: %+v", got["Shelf-1"])
+	}
+
+	// Stepping back rebuilds the earlier state.
+	replay.Seek(replay.Len())
+	replay.Step(-2)
+	got = contents()
+	if replay.Pos() != 3 || len(got["Heater-1"]) != 0 || len(got["Shelf-1"]) != 1 || got["Shelf-1"][0].Freshness != 25*time.Second {
+		t.Errorf("Unexpected storages after the pickup: %+v", got)
+	}
+	out, err := replay.Exec("next")
+	if err != nil || !strings.Contains(out, "Action 4/5 at +15s: move 2 from Shelf-1 to Heater-1 (refill)") {
+		t.Errorf("Unexpected output of next: %q (%v)", out, err)
+	}
+	if got := contents(); len(got["Heater-1"]) != 1 || got["Heater-1"][0].ID != "2" {
+		t.Errorf("Expected order 2 in the heater after the move, got %+v", got)
+	}
+	if _, err := replay.Exec("at soon"); err == nil {
+		t.Errorf("Expected an error for an invalid time")
+	}
+	if errs := replay.Errors(); len(errs) != 0 {
+		t.Errorf("Unexpected inconsistencies: %v", errs)
+	}
+}
+
+func TestReplayDecayRate(t *testing.T) {
+	cfg := config.FulfillmentConfig{Shelves: []config.UnitConfig{{Capacity: 2, DecayMultiplier: 2}}}
+	fs, clk := newSystem(t, cfg)
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_ROOM, time.Minute))
+	clk.Advance(10 * time.Second)
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_ROOM, time.Minute))
+
+	layout := report.LayoutFromConfig(cfg)
+	if want := report.LayoutOf(fs); !reflect.DeepEqual(layout, want) {
+		t.Errorf("Expected the layout of the configuration to match the system's: %+v, want %+v", layout, want)
+	}
+	replay := report.NewReplay(layout, nil, fs.ActionLog())
+	at, err := replay.ParseTime("15")
+	if err != nil || !at.Equal(replay.Start().Add(15*time.Second)) {
+		t.Fatalf("Expected a bare number read as seconds, got %v (%v)", at, err)
+	}
+	if at, err := replay.ParseTime(fmt.Sprintf("@%d", at.UnixMicro())); err != nil || !at.Equal(replay.Start().Add(15*time.Second)) {
+		t.Errorf("Expected @ to read unix microseconds, got %v (%v)", at, err)
+	}
+	replay.SeekTime(at)
+	clk.Advance(5 * time.Second)
+	snap := fs.Snapshot()
+	for _, v := range replay.View() {
+		for _, o := range v.Orders {
+			want, _, _ := snap.Find(o.ID)
+			if o.Freshness != want.RemainingFreshness {
+				t.Errorf("Order %s: expected %v left at the unit's decay rate, got %v", o.ID, want.RemainingFreshness, o.Freshness)
+			}
+		}
+	}
+}
+
+func TestReplayOrderSizes(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumShelves: 1, ShelfCap: 3}
+	fs, _ := newSystem(t, cfg)
+	menu := catalog.Catalog{Items: []catalog.Item{{Name: "Pizza", Temp: config.TEMP_TYPE_ROOM, Freshness: 60, Size: 2}}}
+	pizza, err := workload.ToMenuOrder(css.Order{ID: "1", Name: "Pizza"}, menu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.PlaceOrder(pizza)
+
+	layout := report.LayoutFromConfig(cfg)
+	for _, tc := range []struct {
+		orders []entity.Order
+		used   int
+	}{
+		{nil, 1},
+		{workload.ToOrders([]css.Order{{ID: "1", Name: "Pizza", Temp: config.TEMP_TYPE_ROOM, Freshness: 60}}), 1},
+		{[]entity.Order{pizza}, 2},
+	} {
+		replay := report.NewReplay(layout, tc.orders, fs.ActionLog())
+		replay.Seek(replay.Len())
+		if v := replay.View(); len(v) != 1 || v[0].Used != tc.used {
+			t.Errorf("Expected %d slots used with orders %+v, got %+v", tc.used, tc.orders, v)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"challenge/report"
+	"testing"
+	"time"
+)
+
+func TestSummarizeRun(t *testing.T) {
+	layout := []report.StorageInfo{
+		{Name: "Heater-1", Group: report.GroupHeater, Capacity: 1},
+		{Name: "Shelf-1", Group: report.GroupShelf, Capacity: 2},
+	}
+	orders := []entity.Order{
+		{ID: "1", Temperature: config.TEMP_TYPE_HOT, Freshness: 20 * time.Second},
+		{ID: "2", Temperature: config.TEMP_TYPE_HOT, Freshness: 20 * time.Second},
+		{ID: "3", Temperature: config.TEMP_TYPE_ROOM, Freshness: 10 * time.Second},
+	}
+	sec := int64(time.Second / time.Microsecond)
+	actions := []logic.Action{
+		{Timestamp: 0, OrderID: "1", Action: config.ACTION_TYPE_PLACE, Storage: "Heater-1", Freshness: 10 * time.Second},
+		{Timestamp: 1 * sec, OrderID: "2", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1", Freshness: 10 * time.Second},
+		{Timestamp: 2 * sec, OrderID: "3", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1", Freshness: 10 * time.Second},
+		{Timestamp: 3 * sec, OrderID: "1", Action: config.ACTION_TYPE_PICKUP, Storage: "Heater-1", Freshness: 7 * time.Second},
+		{Timestamp: 3 * sec, OrderID: "2", Action: config.ACTION_TYPE_MOVE, Storage: "Heater-1", Freshness: 8 * time.Second},
+		{Timestamp: 4 * sec, OrderID: "3", Action: config.ACTION_TYPE_DISCARD, Storage: "Shelf-1", Freshness: 8 * time.Second},
+		{Timestamp: 5 * sec, OrderID: "2", Action: config.ACTION_TYPE_PICKUP, Storage: "Heater-1", Freshness: 5 * time.Second},
+	}
+
+	s := report.Summarize(orders, actions, layout)
+
+	if s.Actions[config.ACTION_TYPE_PLACE] != 3 || s.Actions[config.ACTION_TYPE_MOVE] != 1 || s.Actions[config.ACTION_TYPE_DISCARD] != 1 {
+		t.Errorf("Unexpected action counts: %v", s.Actions)
+	}
+	if s.Inconsistent != 0 {
+		t.Errorf("Expected a consistent log, got %d inconsistent actions", s.Inconsistent)
+	}
+	for _, ts := range s.Temperatures {
+		switch ts.Temperature {
+		case config.TEMP_TYPE_HOT:
+			if ts.DiscardRate != 0 || ts.PickedUp != 2 {
+				t.Errorf("Unexpected hot stats: %+v", ts)
+			}
+		case config.TEMP_TYPE_ROOM:
+			if ts.DiscardRate != 1 {
+				t.Errorf("Unexpected room stats: %+v", ts)
+			}
+		}
+	}
+	if s.Freshness.Count != 2 || s.Freshness.Min != 0.5 || s.Freshness.Mean != 0.6 {
+		t.Errorf("Unexpected freshness stats: %+v", s.Freshness)
+	}
+	if s.MoveCounts[1] != 1 || s.MoveCounts[0] != 2 {
+		t.Errorf("Unexpected move counts: %v", s.MoveCounts)
+	}
+	for _, st := range s.Storages {
+		if st.Name == "Shelf-1" && (st.Peak != 2 || st.PeakUtilization != 1 || st.PeakAt != 2) {
+			t.Errorf("Unexpected shelf peak: %+v", st)
+		}
+	}
+	if last := s.ShelfOccupancy[len(s.ShelfOccupancy)-1]; last.Orders != 0 {
+		t.Errorf("Expected the shelf to end empty, got %+v", last)
+	}
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReservations(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 2, NumShelves: 1, ShelfCap: 4}
+	fs, clk := newSystem(t, cfg)
+
+	// Hold a heater slot for the VIP order for the next 30 seconds.
+	id, err := fs.Reserve(logic.GroupHeater, entity.Reservation{Slots: 1, OrderID: "vip", Until: clk.Now().Add(30 * time.Second)})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_HOT, time.Minute))
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_HOT, time.Minute))
+	if _, where, _ := fs.Snapshot().Find("2"); where != "Shelf-1" {
+		t.Errorf("Expected order 2 on the shelf, the last heater slot being reserved, got %q", where)
+	}
+	if snap := fs.Snapshot(); len(snap.Reservations) != 1 || snap.Reservations[0].ID != id || !snap.Reservations[0].Active {
+		t.Errorf("Expected the active reservation in the snapshot, got %+v", snap.Reservations)
+	}
+
+	// The VIP order takes its slot, which ends the reservation.
+	fs.PlaceOrder(order("vip", config.TEMP_TYPE_HOT, time.Minute))
+	if _, where, _ := fs.Snapshot().Find("vip"); where != "Heater-1" {
+		t.Errorf("Expected the VIP order in the heater, got %q", where)
+	}
+	if n := len(fs.Snapshot().Reservations); n != 0 {
+		t.Errorf("Expected the reservation used up, got %d left", n)
+	}
+
+	// A window reservation for hot orders keeps rescued shelf orders out of a freed slot,
+	// and expires on its own.
+	if _, err := fs.Reserve(logic.GroupHeater, entity.Reservation{Slots: 1, Temperature: config.TEMP_TYPE_HOT, Until: clk.Now().Add(10 * time.Second)}); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	fs.PickupOrder("1")
+	if _, where, _ := fs.Snapshot().Find("2"); where != "Shelf-1" {
+		t.Errorf("Expected order 2 to stay on the shelf while the slot is reserved, got %q", where)
+	}
+	clk.Advance(10 * time.Second)
+	snap := fs.Snapshot()
+	if _, where, _ := snap.Find("2"); where != "Heater-1" {
+		t.Errorf("Expected order 2 moved in once the reservation expired, got %q", where)
+	}
+	if len(snap.Reservations) != 0 {
+		t.Errorf("Expected the reservation expired, got %+v", snap.Reservations)
+	}
+
+	// Reservations can also be made and cancelled from the admin console.
+	out, err := fs.ExecAdmin("reserve shelf 2 temp=cold in=1m for=5m")
+	if err != nil || !strings.Contains(out, "R3") {
+		t.Fatalf("Unexpected reserve output %q, %v", out, err)
+	}
+	if out, _ := fs.ExecAdmin("status"); !strings.Contains(out, "R3") || !strings.Contains(out, "(pending)") {
+		t.Errorf("Expected the pending reservation in the status, got %q", out)
+	}
+	if _, err := fs.ExecAdmin("unreserve R3"); err != nil {
+		t.Errorf("unreserve: %v", err)
+	}
+	if _, err := fs.Reserve("freezer", entity.Reservation{Slots: 1}); err == nil {
+		t.Errorf("Expected an error for an unknown group")
+	}
+	checkInvariants(t, fs)
+}
+
+func TestReservationSlotsAreUsedUp(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 2, NumShelves: 1, ShelfCap: 2}
+	fs, _ := newSystem(t, cfg)
+
+	for _, id := range []string{"1", "2", "3"} {
+		fs.PlaceOrder(order(id, config.TEMP_TYPE_HOT, time.Minute))
+	}
+	if _, err := fs.Reserve(logic.GroupHeater, entity.Reservation{Slots: 1, Temperature: config.TEMP_TYPE_HOT}); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	// The freed slot is held for a hot order being placed, not for order 3 on the shelf.
+	fs.PickupOrder("1")
+	if _, where, _ := fs.Snapshot().Find("3"); where != "Shelf-1" {
+		t.Errorf("Expected order 3 to stay on the shelf, got %q", where)
+	}
+	// Order 4 takes the reserved slot, so the next freed slot goes to order 3.
+	fs.PlaceOrder(order("4", config.TEMP_TYPE_HOT, time.Minute))
+	if n := len(fs.Snapshot().Reservations); n != 0 {
+		t.Errorf("Expected the reservation used up, got %d left", n)
+	}
+	fs.PickupOrder("2")
+	if _, where, _ := fs.Snapshot().Find("3"); where != "Heater-1" {
+		t.Errorf("Expected order 3 moved into the heater, got %q", where)
+	}
+	checkInvariants(t, fs)
+}
+
+func TestMovedOrdersUseUpCoveringReservations(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 2, NumShelves: 1, ShelfCap: 2}
+	fs, _ := newSystem(t, cfg)
+
+	for _, id := range []string{"1", "2", "3"} {
+		fs.PlaceOrder(order(id, config.TEMP_TYPE_HOT, time.Minute))
+	}
+	if _, err := fs.Reserve(logic.GroupHeater, entity.Reservation{Slots: 1, Temperature: config.TEMP_TYPE_HOT}); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	fs.PickupOrder("1")
+
+	// An operator moving a hot order into the heater takes the slot held for hot orders.
+	if err := fs.MoveOrder("3", "Heater-1", "bob", "hot order waiting"); err != nil {
+		t.Fatalf("MoveOrder: %v", err)
+	}
+	if n := len(fs.Snapshot().Reservations); n != 0 {
+		t.Errorf("Expected the moved order to use up the reservation, got %d left", n)
+	}
+
+	// An order moved into the slots held for it uses them up too.
+	fs.PlaceOrder(order("4", config.TEMP_TYPE_HOT, time.Minute))
+	if _, err := fs.Reserve(logic.GroupHeater, entity.Reservation{Slots: 1, OrderID: "4"}); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	fs.PickupOrder("2")
+	if _, where, _ := fs.Snapshot().Find("4"); where != "Heater-1" {
+		t.Errorf("Expected order 4 moved into the heater, got %q", where)
+	}
+	if n := len(fs.Snapshot().Reservations); n != 0 {
+		t.Errorf("Expected the reservation of order 4 to end, got %d left", n)
+	}
+	checkInvariants(t, fs)
+}
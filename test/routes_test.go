@@ -0,0 +1,82 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"challenge/report"
+	"testing"
+	"time"
+)
+
+func TestCompatibilityRoutes(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers: 1, CoolerCap: 1,
+		NumShelves: 1, ShelfCap: 1,
+		Compatibility: map[string][]config.RouteConfig{
+			// Room orders may use the spare cooler, where they keep twice as long.
+			config.TEMP_TYPE_ROOM: {{Group: logic.GroupShelf}, {Group: logic.GroupCooler, DecayMultiplier: 0.5}},
+			// Unknown groups are skipped.
+			config.TEMP_TYPE_HOT: {{Group: "oven"}, {Group: logic.GroupShelf}},
+		},
+	}
+	fs, clk := newSystem(t, cfg)
+
+	fs.PlaceOrder(order("r1", config.TEMP_TYPE_ROOM, time.Minute))
+	fs.PlaceOrder(order("r2", config.TEMP_TYPE_ROOM, time.Minute))
+	if _, where, _ := fs.Snapshot().Find("r2"); where != "Cooler-1" {
+		t.Fatalf("Expected r2 in the spare cooler, got %q", where)
+	}
+	clk.Advance(10 * time.Second)
+	snap := fs.Snapshot()
+	if o, _, _ := snap.Find("r1"); o.RemainingFreshness != 50*time.Second {
+		t.Errorf("Expected 50s left for r1 on the shelf, got %v", o.RemainingFreshness)
+	}
+	if o, _, _ := snap.Find("r2"); o.RemainingFreshness != 55*time.Second {
+		t.Errorf("Expected 55s left for r2 in the cooler, got %v", o.RemainingFreshness)
+	}
+
+	// A cold order falls back to the shelf, discarding r1 there.
+	fs.PlaceOrder(order("c1", config.TEMP_TYPE_COLD, time.Minute))
+	if _, where, _ := fs.Snapshot().Find("c1"); where != "Shelf-1" {
+		t.Errorf("Expected c1 on the shelf, got %q", where)
+	}
+
+	// Once the cooler frees up, c1 moves in: the cooler comes first in its route.
+	fs.PickupOrder("r2")
+	if _, where, _ := fs.Snapshot().Find("c1"); where != "Cooler-1" {
+		t.Errorf("Expected c1 moved to the cooler, got %q", where)
+	}
+
+	// Hot orders follow their configured route straight to the shelf.
+	fs.PlaceOrder(order("h1", config.TEMP_TYPE_HOT, time.Minute))
+	if _, where, _ := fs.Snapshot().Find("h1"); where != "Shelf-1" {
+		t.Errorf("Expected h1 on the shelf, got %q", where)
+	}
+
+	// With both groups full, a room order makes room on the shelf, the discard group,
+	// rather than discarding c1 from the cooler at the end of its route.
+	fs.PlaceOrder(order("r3", config.TEMP_TYPE_ROOM, time.Minute))
+	snap = fs.Snapshot()
+	if _, where, _ := snap.Find("r3"); where != "Shelf-1" {
+		t.Errorf("Expected r3 on the shelf, got %q", where)
+	}
+	if _, where, _ := snap.Find("c1"); where != "Cooler-1" {
+		t.Errorf("Expected c1 to stay in the cooler, got %q", where)
+	}
+	checkInvariants(t, fs)
+
+	// The validator follows the configured routes: r2 in the cooler is no violation.
+	fs.PickupOrder("c1")
+	fs.PickupOrder("r3")
+	orders := []entity.Order{
+		order("r1", config.TEMP_TYPE_ROOM, time.Minute),
+		order("r2", config.TEMP_TYPE_ROOM, time.Minute),
+		order("c1", config.TEMP_TYPE_COLD, time.Minute),
+		order("h1", config.TEMP_TYPE_HOT, time.Minute),
+		order("r3", config.TEMP_TYPE_ROOM, time.Minute),
+	}
+	if violations := report.Validate(orders, fs.ActionLog(), report.LayoutOf(fs)); len(violations) != 0 {
+		t.Errorf("Unexpected violations: %v", violations)
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/logic"
+	"challenge/sensor"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSensorTemperature(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := sensor.New(config.SensorConfig{
+		SetpointC:      4,
+		DriftCPerMin:   1,
+		DoorOpenDeltaC: 6,
+		DoorOpenEveryS: 120,
+		RecoveryS:      30,
+		Decay:          []config.DecayPoint{{TempC: 14, Multiplier: 3}, {TempC: 4, Multiplier: 1}},
+	}, start)
+	for _, tc := range []struct {
+		at   time.Duration
+		want float64
+	}{
+		{0, 4},
+		{60 * time.Second, 5},
+		{120 * time.Second, 12},   // Drifted to 6, plus the door opening.
+		{135 * time.Second, 9.25}, // Halfway through recovery.
+		{150 * time.Second, 6.5},
+	} {
+		if got := s.TemperatureAt(start.Add(tc.at)); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("At %v: expected %g C, got %g C", tc.at, tc.want, got)
+		}
+	}
+	if got := s.MultiplierAt(start.Add(120 * time.Second)); math.Abs(got-2.6) > 1e-9 {
+		t.Errorf("Expected a 2.6x multiplier at 12 C, got %g", got)
+	}
+
+	// Changing the drift keeps the temperature continuous.
+	s.SetDrift(start.Add(60*time.Second), -1)
+	if got := s.TemperatureAt(start.Add(90 * time.Second)); math.Abs(got-4.5) > 1e-9 {
+		t.Errorf("Expected 4.5 C after the drift reversed, got %g C", got)
+	}
+
+	curve := []config.DecayPoint{{TempC: 0, Multiplier: 1}, {TempC: 10, Multiplier: 2}}
+	for temp, want := range map[float64]float64{-5: 1, 5: 1.5, 20: 2} {
+		if got := sensor.Multiplier(curve, temp); got != want {
+			t.Errorf("Multiplier at %g C: expected %g, got %g", temp, want, got)
+		}
+	}
+	if got := sensor.Multiplier(nil, 30); got != 1 {
+		t.Errorf("Expected a 1x multiplier without a curve, got %g", got)
+	}
+}
+
+func TestFailingCooler(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers: 1, CoolerCap: 2,
+		NumShelves: 1, ShelfCap: 2,
+		Sensors: map[string]*config.SensorConfig{
+			logic.GroupCooler: {
+				SetpointC:      4,
+				DoorOpenDeltaC: 10,
+				RecoveryS:      60,
+				Decay:          []config.DecayPoint{{TempC: 4, Multiplier: 1}, {TempC: 14, Multiplier: 3}},
+			},
+		},
+	}
+	fs, clk := newSystem(t, cfg)
+
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_COLD, time.Minute))
+	remaining := func() time.Duration {
+		o, _, _ := fs.Snapshot().Find("1")
+		return o.RemainingFreshness
+	}
+	near := func(got, want time.Duration) bool {
+		return (got - want).Abs() < time.Millisecond
+	}
+
+	// At the setpoint, the order decays normally.
+	clk.Advance(6 * time.Second)
+	if got := remaining(); !near(got, 24*time.Second) {
+		t.Errorf("Expected 24s left, got %v", got)
+	}
+
+	// The cooler starts failing: 10 degrees per minute, so 5 C after 6s (1.2x).
+	if err := fs.SetDrift("Cooler-1", 10); err != nil {
+		t.Fatalf("SetDrift: %v", err)
+	}
+	clk.Advance(6 * time.Second)
+	if got := remaining(); !near(got, 24*time.Second-6*time.Second) {
+		t.Errorf("Expected 18s left, got %v", got)
+	}
+	clk.Advance(6 * time.Second)
+	if got := remaining(); !near(got, 18*time.Second-7200*time.Millisecond) {
+		t.Errorf("Expected 10.8s left, got %v", got)
+	}
+	snap := fs.Snapshot()
+	if cooler, _ := snap.Storage("Cooler-1"); cooler.TempC == nil || math.Abs(*cooler.TempC-6) > 1e-9 || math.Abs(cooler.Decay-1.4) > 1e-9 {
+		t.Errorf("Expected the cooler at 6 C decaying 1.4x, got %+v", cooler)
+	}
+
+	// Opening the door adds 10 degrees: 3x decay.
+	if err := fs.OpenDoor("Cooler-1"); err != nil {
+		t.Fatalf("OpenDoor: %v", err)
+	}
+	if cooler, _ := fs.Snapshot().Storage("Cooler-1"); math.Abs(cooler.Decay-3) > 1e-9 {
+		t.Errorf("Expected 3x decay with the door open, got %g", cooler.Decay)
+	}
+	if err := fs.OpenDoor("Shelf-1"); err == nil {
+		t.Errorf("Expected an error for a unit without a sensor")
+	}
+	checkInvariants(t, fs)
+}
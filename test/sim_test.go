@@ -0,0 +1,138 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"challenge/report"
+	"challenge/sim"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func simOrders(n int) []entity.Order {
+	temps := []string{config.TEMP_TYPE_HOT, config.TEMP_TYPE_COLD, config.TEMP_TYPE_ROOM}
+	var orders []entity.Order
+	for i := 0; i < n; i++ {
+		freshness := time.Duration(20+i%7*10) * time.Second
+		orders = append(orders, entity.Order{
+			ID:               fmt.Sprintf("%d", i),
+			Temperature:      temps[i%len(temps)],
+			Freshness:        freshness,
+			InitialFreshness: freshness,
+		})
+	}
+	return orders
+}
+
+func TestSimulationIsDeterministicAndValid(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 2, NumHeaters: 1, HeaterCap: 2, NumShelves: 1, ShelfCap: 3}
+	sc := sim.Scenario{Orders: simOrders(60), Rate: 200 * time.Millisecond, Min: 2 * time.Second, Max: 4 * time.Second, Seed: 7}
+
+	first := sim.Run(cfg, sc)
+	second := sim.Run(cfg, sc)
+	if len(first.Actions) != len(second.Actions) {
+		t.Fatalf("Runs with the same seed differ: %d vs %d actions", len(first.Actions), len(second.Actions))
+	}
+	for i := range first.Actions {
+		if first.Actions[i] != second.Actions[i] {
+			t.Fatalf("Runs with the same seed differ at action %d: %+v vs %+v", i, first.Actions[i], second.Actions[i])
+		}
+	}
+	if !first.Valid() {
+		t.Errorf("Expected a valid run, got violations: %v", first.Violations)
+	}
+	if first.Summary.Actions[config.ACTION_TYPE_DISCARD] == 0 {
+		t.Errorf("Expected the small shelf to force discards")
+	}
+	if first.Actions[0].Timestamp != sim.Epoch.UnixMicro() {
+		t.Errorf("Expected actions on virtual time, first timestamp %d", first.Actions[0].Timestamp)
+	}
+}
+
+func TestValidateDetectsViolations(t *testing.T) {
+	layout := []report.StorageInfo{
+		{Name: "Heater-1", Group: report.GroupHeater, Capacity: 1},
+		{Name: "Shelf-1", Group: report.GroupShelf, Capacity: 1},
+	}
+	orders := []entity.Order{
+		{ID: "1", Temperature: config.TEMP_TYPE_HOT},
+		{ID: "2", Temperature: config.TEMP_TYPE_ROOM},
+		{ID: "3", Temperature: config.TEMP_TYPE_ROOM},
+	}
+	actions := []logic.Action{
+		{Timestamp: 10, OrderID: "1", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1"},
+		{Timestamp: 20, OrderID: "2", Action: config.ACTION_TYPE_PLACE, Storage: "Heater-1"},
+		{Timestamp: 15, OrderID: "1", Action: config.ACTION_TYPE_PLACE, Storage: "Shelf-1"},
+		{Timestamp: 30, OrderID: "2", Action: config.ACTION_TYPE_PICKUP, Storage: "Heater-1"},
+	}
+
+	violations := report.Validate(orders, actions, layout)
+	want := []string{
+		"action 1 (order 2): room order stored in heater Heater-1",
+		"action 2 (order 1): timestamp 15 is before the previous action's 20",
+		"action 2 (order 1): order 1 placed again while stored in Shelf-1",
+		"action 3 (order 2): room order stored in heater Heater-1",
+		"action 3 (order 2): picked up with no freshness left (0s)",
+		"order 1: still stored in Shelf-1 at the end of the log",
+		"order 3: never placed",
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("Expected %d violations, got %d: %v", len(want), len(violations), violations)
+	}
+	for i, v := range violations {
+		if v.Error() != want[i] {
+			t.Errorf("Violation %d: got %q, want %q", i, v.Error(), want[i])
+		}
+	}
+}
+
+func TestPlanFindsCheapestFeasibleConfiguration(t *testing.T) {
+	one := sim.Range{Min: 1, Max: 1, Step: 1}
+	shelfCap, err := sim.ParseRange("1:30:1")
+	if err != nil {
+		t.Fatalf("ParseRange failed: %v", err)
+	}
+	spec := sim.PlanSpec{
+		Orders:         simOrders(40),
+		Rate:           100 * time.Millisecond,
+		Min:            2 * time.Second,
+		Max:            3 * time.Second,
+		Seeds:          []int64{1, 2},
+		NumCoolers:     one,
+		CoolerCap:      one,
+		NumHeaters:     one,
+		HeaterCap:      one,
+		NumShelves:     one,
+		ShelfCap:       shelfCap,
+		Costs:          sim.DefaultCosts(),
+		MaxDiscardRate: 0,
+		MinFreshness:   0,
+		Mode:           sim.PlanGrid,
+		Parallel:       4,
+	}
+
+	candidates := sim.Plan(spec)
+	if len(candidates) != 30 {
+		t.Fatalf("Expected 30 candidates, got %d", len(candidates))
+	}
+	best, ok := sim.Cheapest(candidates)
+	if !ok {
+		t.Fatalf("Expected a feasible configuration")
+	}
+	for _, c := range candidates {
+		if c.Config.ShelfCap < best.Config.ShelfCap && c.Feasible {
+			t.Errorf("Cheaper feasible configuration %+v was not chosen", c.Config)
+		}
+	}
+	if best.Config.ShelfCap <= 1 || best.DiscardRate.Mean != 0 {
+		t.Errorf("Unexpected recommendation: %+v", best)
+	}
+
+	spec.Mode = sim.PlanSearch
+	searched, ok := sim.Cheapest(sim.Plan(spec))
+	if !ok || searched.Config.ShelfCap != best.Config.ShelfCap {
+		t.Errorf("Search found %+v, grid found %+v", searched.Config, best.Config)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"challenge/report"
+	"testing"
+	"time"
+)
+
+func TestLargeOrderFreesSeveralSmallOrders(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumShelves: 2, ShelfCap: 3}
+	fs, _ := newSystem(t, cfg)
+
+	room := func(id string, size int, freshness time.Duration) entity.Order {
+		o := order(id, config.TEMP_TYPE_ROOM, freshness)
+		o.Size = size
+		return o
+	}
+	// Shelf-1 holds 1 (size 2) and 2; Shelf-2 holds 3, 4 and 5.
+	orders := []entity.Order{
+		room("1", 2, 90*time.Second),
+		room("2", 1, 10*time.Second),
+		room("3", 1, 20*time.Second),
+		room("4", 1, 30*time.Second),
+		room("5", 1, 40*time.Second),
+		room("tray", 3, 60*time.Second),
+	}
+	for _, o := range orders {
+		fs.PlaceOrder(o)
+	}
+
+	// The least fresh order is 2 on Shelf-1; clearing Shelf-1 also discards 1, leaving Shelf-2 alone.
+	var discarded []string
+	for _, a := range fs.ActionLog() {
+		if a.Action == config.ACTION_TYPE_DISCARD {
+			discarded = append(discarded, a.OrderID)
+		}
+	}
+	if len(discarded) != 2 || discarded[0] != "2" || discarded[1] != "1" {
+		t.Errorf("Expected orders 2 and 1 discarded, got %v", discarded)
+	}
+	snap := fs.Snapshot()
+	if shelf, _ := snap.Storage("Shelf-1"); shelf.Used != 3 || len(shelf.Orders) != 1 || shelf.Orders[0].ID != "tray" {
+		t.Errorf("Expected the tray alone on Shelf-1, got %+v", shelf)
+	}
+	if shelf, _ := snap.Storage("Shelf-2"); shelf.Used != 3 || len(shelf.Orders) != 3 {
+		t.Errorf("Expected Shelf-2 untouched, got %+v", shelf)
+	}
+
+	// An order larger than any shelf is rejected without discarding anything else.
+	banquet := room("banquet", 4, time.Minute)
+	orders = append(orders, banquet)
+	fs.PlaceOrder(banquet)
+	actions := fs.ActionLog()
+	if n := len(actions); n != 9 || actions[8].Action != config.ACTION_TYPE_DISCARD || actions[8].OrderID != "banquet" || actions[8].Rule != logic.RuleRejected {
+		t.Errorf("Expected only the oversized order rejected, got %+v", actions[len(actions)-1])
+	}
+	checkInvariants(t, fs)
+	if violations := report.Validate(orders, fs.ActionLog(), report.LayoutOf(fs)); len(violations) != 4 {
+		// Orders 3, 4, 5 and the tray are still stored at the end of the log.
+		t.Errorf("Expected only still-stored violations, got %v", violations)
+	}
+}
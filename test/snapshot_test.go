@@ -0,0 +1,62 @@
+package test
+
+import (
+	"challenge/clock"
+	"challenge/config"
+	"challenge/entity"
+	"challenge/logic"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSnapshotIsPointInTimeCopy(t *testing.T) {
+	cfg := config.FulfillmentConfig{
+		NumCoolers: 1,
+		CoolerCap:  1,
+		NumHeaters: 1,
+		HeaterCap:  1,
+		NumShelves: 1,
+		ShelfCap:   2,
+	}
+	clk := clock.NewVirtual(time.Unix(1000, 0))
+	fs := logic.NewFulfillmentSystem(cfg, logic.WithClock(clk))
+	defer fs.Close()
+
+	fs.PlaceOrder(entity.Order{ID: "1", Temperature: config.TEMP_TYPE_HOT, Freshness: 20 * time.Second})
+	fs.PlaceOrder(entity.Order{ID: "2", Temperature: config.TEMP_TYPE_HOT, Freshness: 20 * time.Second})
+	fs.PlaceOrder(entity.Order{ID: "3", Name: "Chips", Temperature: config.TEMP_TYPE_ROOM, Freshness: 30 * time.Second})
+	clk.Advance(4 * time.Second)
+
+	snap := fs.Snapshot()
+	if len(snap.Storages) != 3 {
+		t.Fatalf("Expected 3 storages, got %d", len(snap.Storages))
+	}
+	if o, where, ok := snap.Find("2"); !ok || where != "Shelf-1" || o.RemainingFreshness != 6*time.Second {
+		t.Errorf("Unexpected order 2: %+v in %q", o, where)
+	}
+	if shelf, ok := snap.Storage("Shelf-1"); !ok || shelf.Capacity != 2 || len(shelf.Orders) != 2 || shelf.Orders[0].ID != "2" {
+		t.Errorf("Unexpected shelf snapshot: %+v", shelf)
+	}
+
+	// Later operations must not show up in an earlier snapshot.
+	fs.PickupOrder("1")
+	if _, where, ok := snap.Find("1"); !ok || where != "Heater-1" {
+		t.Errorf("Snapshot changed after pickup: order 1 in %q", where)
+	}
+	if _, _, ok := fs.Snapshot().Find("1"); ok {
+		t.Errorf("Order 1 should be gone from a new snapshot")
+	}
+
+	data, err := snap.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded logic.Snapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if o, _, ok := decoded.Find("3"); !ok || o.Name != "Chips" || o.RemainingFreshness != 26*time.Second {
+		t.Errorf("Unexpected decoded order 3: %+v", o)
+	}
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"bytes"
+	"challenge/actionlog"
+	"challenge/config"
+	"challenge/logic"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecisionTrace(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumCoolers: 1, CoolerCap: 1, NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 2, TraceDecisions: true}
+	fs, _ := newSystem(t, cfg)
+
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_COLD, time.Minute))
+	fs.PlaceOrder(order("2", config.TEMP_TYPE_COLD, time.Minute))
+	fs.PlaceOrder(order("3", config.TEMP_TYPE_ROOM, 20*time.Second))
+	fs.PlaceOrder(order("4", config.TEMP_TYPE_ROOM, time.Minute))
+	fs.PickupOrder("1")
+
+	want := []struct {
+		id, action, rule, source, target string
+		candidates                       []string
+	}{
+		{"1", config.ACTION_TYPE_PLACE, logic.RuleIdeal, "", "Cooler-1", nil},
+		{"2", config.ACTION_TYPE_PLACE, logic.RuleIdealFull, "", "Shelf-1", nil},
+		{"3", config.ACTION_TYPE_PLACE, logic.RuleIdeal, "", "Shelf-1", nil},
+		{"3", config.ACTION_TYPE_DISCARD, logic.RuleFallbackFull, "Shelf-1", "", []string{"3", "2"}},
+		{"4", config.ACTION_TYPE_PLACE, logic.RuleFallbackFull, "", "Shelf-1", nil},
+		{"1", config.ACTION_TYPE_PICKUP, logic.RulePickup, "Cooler-1", "", nil},
+		{"2", config.ACTION_TYPE_MOVE, logic.RuleRefill, "Shelf-1", "Cooler-1", []string{"2"}},
+	}
+	actions := fs.ActionLog()
+	if len(actions) != len(want) {
+		t.Fatalf("Expected %d actions, got %+v", len(want), actions)
+	}
+	for i, w := range want {
+		a := actions[i]
+		if a.OrderID != w.id || a.Action != w.action {
+			t.Errorf("Action %d: expected %s of order %s, got %+v", i, w.action, w.id, a)
+			continue
+		}
+		if a.Rule != w.rule || a.Source != w.source || a.Target != w.target {
+			t.Errorf("Action %d: expected %s from %q to %q, got %+v", i, w.rule, w.source, w.target, a)
+		}
+		if w.candidates == nil {
+			if a.Decision != nil {
+				t.Errorf("Action %d: expected no decision, got %+v", i, a.Decision)
+			}
+			continue
+		}
+		d := a.Decision
+		if d == nil || len(d.Candidates) != len(w.candidates) {
+			t.Errorf("Action %d: unexpected decision %+v", i, d)
+			continue
+		}
+		for j, id := range w.candidates {
+			if d.Candidates[j].OrderID != id {
+				t.Errorf("Action %d: expected candidate %d to be %s, got %+v", i, j, id, d.Candidates[j])
+			}
+		}
+	}
+
+	// Per-order queries, from code and the admin console.
+	if trace := fs.Trace("3"); len(trace) != 2 || trace[1].Decision.Candidates[1].Freshness != 30*time.Second {
+		t.Errorf("Unexpected trace of order 3: %+v", trace)
+	}
+	if out, err := fs.ExecAdmin("trace 2"); err != nil || !strings.Contains(out, "move    Shelf-1 -> Cooler-1 30s [refill]") {
+		t.Errorf("Unexpected trace output %q (%v)", out, err)
+	}
+
+	// Decisions survive a JSONL round trip.
+	var buf bytes.Buffer
+	if err := actionlog.WriteJSONL(&buf, actions); err != nil {
+		t.Fatalf("WriteJSONL: %v", err)
+	}
+	read, err := actionlog.ReadJSONL(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSONL: %v", err)
+	}
+	if a := read[3]; a.Rule != logic.RuleFallbackFull || a.Source != "Shelf-1" || a.Decision == nil || len(a.Decision.Candidates) != 2 || a.Decision.Candidates[0].Freshness != 20*time.Second {
+		t.Errorf("Unexpected action read back: %+v", a)
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"challenge/config"
+	"challenge/entity"
+	"testing"
+	"time"
+)
+
+func TestConfiguredUnits(t *testing.T) {
+	off := false
+	cfg := config.FulfillmentConfig{
+		Coolers: []config.UnitConfig{
+			{Name: "Walk-in", Capacity: 4},
+			{Name: "Under-counter", Capacity: 1, DecayMultiplier: 2},
+			{Capacity: 2, Enabled: &off},
+		},
+		NumHeaters: 1, HeaterCap: 1,
+		NumShelves: 1, ShelfCap: 2,
+		FitStrategy: entity.FitBest,
+	}
+	fs, clk := newSystem(t, cfg)
+
+	// Best fit fills the small cooler first, then the walk-in; the disabled one stays empty.
+	for _, id := range []string{"1", "2", "3"} {
+		fs.PlaceOrder(order(id, config.TEMP_TYPE_COLD, time.Minute))
+	}
+	snap := fs.Snapshot()
+	if s, ok := snap.Storage("Under-counter"); !ok || len(s.Orders) != 1 || s.Orders[0].ID != "1" || s.Decay != 2 {
+		t.Errorf("Expected order 1 alone in the under-counter cooler, got %+v", s)
+	}
+	if s, ok := snap.Storage("Walk-in"); !ok || s.Used != 2 || s.Decay != 1 {
+		t.Errorf("Expected two orders in the walk-in cooler, got %+v", s)
+	}
+	if s, ok := snap.Storage("Cooler-3"); !ok || s.Status != entity.StatusOffline || s.Used != 0 {
+		t.Errorf("Expected Cooler-3 offline and empty, got %+v", s)
+	}
+
+	// The under-counter cooler uses up freshness twice as fast.
+	clk.Advance(10 * time.Second)
+	snap = fs.Snapshot()
+	small, _ := snap.Storage("Under-counter")
+	big, _ := snap.Storage("Walk-in")
+	if got := small.Orders[0].RemainingFreshness; got != 10*time.Second {
+		t.Errorf("Expected 10s left in the under-counter cooler, got %v", got)
+	}
+	if got := big.Orders[0].RemainingFreshness; got != 20*time.Second {
+		t.Errorf("Expected 20s left in the walk-in cooler, got %v", got)
+	}
+	checkInvariants(t, fs)
+}
+
+func TestMovesCarryFreshnessOver(t *testing.T) {
+	cfg := config.FulfillmentConfig{NumHeaters: 1, HeaterCap: 1, NumShelves: 1, ShelfCap: 1}
+	fs, clk := newSystem(t, cfg)
+
+	// 30s of heater life: 10s in the heater, 10s on the shelf, then back to the heater.
+	fs.PlaceOrder(order("1", config.TEMP_TYPE_HOT, time.Minute))
+	clk.Advance(10 * time.Second)
+	if err := fs.SetUnitStatus("Heater-1", entity.StatusOffline); err != nil {
+		t.Fatalf("SetUnitStatus: %v", err)
+	}
+	clk.Advance(10 * time.Second)
+	if err := fs.SetUnitStatus("Heater-1", entity.StatusOnline); err != nil {
+		t.Fatalf("SetUnitStatus: %v", err)
+	}
+	o, where, _ := fs.Snapshot().Find("1")
+	if where != "Heater-1" || o.Moves != 2 || o.RemainingFreshness != 10*time.Second {
+		t.Errorf("Expected order 1 back in the heater with 10s left, got %q %+v", where, o)
+	}
+	clk.Advance(5 * time.Second)
+	if o, _, _ := fs.Snapshot().Find("1"); o.RemainingFreshness != 5*time.Second {
+		t.Errorf("Expected 5s left after another 5s in the heater, got %v", o.RemainingFreshness)
+	}
+}
+
+func TestFitStrategies(t *testing.T) {
+	storages := func() []*entity.Storage {
+		a, b := entity.NewStorage("A", 4), entity.NewStorage("B", 2)
+		a.Add(&entity.StoredOrder{Order: entity.Order{ID: "x"}})
+		return []*entity.Storage{a, b}
+	}
+	order := entity.Order{ID: "new"}
+	for fit, want := range map[string]string{
+		entity.FitFirst:       "A",
+		entity.FitBest:        "B",
+		entity.FitLeastLoaded: "B",
+		"":                    "A",
+	} {
+		group := &entity.StorageGroup{Storages: storages(), Fit: fit}
+		if got := group.Pick(order); got == nil || got.Name != want {
+			t.Errorf("%q: expected %s, got %+v", fit, want, got)
+		}
+	}
+	full := &entity.StorageGroup{Storages: []*entity.Storage{entity.NewStorage("C", 0)}, Fit: entity.FitBest}
+	if got := full.Pick(order); got != nil {
+		t.Errorf("Expected no storage for a full group, got %s", got.Name)
+	}
+}
@@ -0,0 +1,216 @@
+package workload
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"challenge/arrival"
+	"challenge/catalog"
+	css "challenge/client"
+)
+
+// idAlphabet is the set of characters used in generated order IDs.
+const idAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// GenSpec describes a synthetic workload.
+type GenSpec struct {
+	Count   int
+	Seed    int64
+	Catalog catalog.Catalog
+	Mix     map[string]float64 // Share of orders per temperature; item weights decide alone if empty.
+
+	// Timestamps, written only when Timestamps is set.
+	Timestamps bool
+	Start      time.Time     // Arrival time of the run's start.
+	Arrivals   arrival.Model // Arrival process; constant arrivals every Rate if nil.
+	Rate       time.Duration
+	MinPickup  time.Duration
+	MaxPickup  time.Duration
+}
+
+// GeneratedOrder is a client order with optional arrival and pickup times, in Unix
+// microseconds. Files of generated orders load with Load like any other order file, or
+// with LoadGenerated to keep the times.
+type GeneratedOrder struct {
+	css.Order
+	ArriveAt int64 `json:"arrive_at,omitempty"`
+	PickupAt int64 `json:"pickup_at,omitempty"`
+}
+
+// Timed reports whether the order records both its arrival and pickup times.
+func (o GeneratedOrder) Timed() bool {
+	return o.ArriveAt != 0 && o.PickupAt != 0
+}
+
+// Generate draws a workload from a catalog. The same spec always yields the same orders.
+func Generate(spec GenSpec) ([]GeneratedOrder, error) {
+	if err := spec.Catalog.Validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(spec.Seed))
+	pick, err := itemPicker(spec.Catalog, spec.Mix)
+	if err != nil {
+		return nil, err
+	}
+	model := spec.Arrivals
+	if model == nil {
+		model = arrival.Constant{Interval: spec.Rate}
+	}
+	arrivals := model.Start(rand.New(rand.NewSource(arrival.Seed(spec.Seed))))
+
+	ids := make(map[string]bool)
+	orders := make([]GeneratedOrder, 0, spec.Count)
+	for len(orders) < spec.Count {
+		id := randomID(rng)
+		if ids[id] {
+			continue
+		}
+		ids[id] = true
+		item := pick(rng)
+		o := GeneratedOrder{Order: css.Order{
+			ID:   id,
+			Name: item.Name,
+			Temp: item.Temp,
+		}}
+		lo, hi := item.FreshnessRange()
+		o.Freshness = lo + rng.Intn(hi-lo+1)
+		if spec.Timestamps {
+			arriveAt := spec.Start.Add(arrivals())
+			delay := spec.MinPickup
+			if spec.MaxPickup > spec.MinPickup {
+				delay += time.Duration(rng.Int63n(int64(spec.MaxPickup - spec.MinPickup)))
+			}
+			o.ArriveAt = arriveAt.UnixMicro()
+			o.PickupAt = arriveAt.Add(delay).UnixMicro()
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// itemPicker returns a function drawing catalog items by temperature mix and weight.
+func itemPicker(c catalog.Catalog, mix map[string]float64) (func(*rand.Rand) catalog.Item, error) {
+	if len(mix) == 0 {
+		return weighted(c.Items), nil
+	}
+	temps := make([]string, 0, len(mix))
+	for temp := range mix {
+		temps = append(temps, temp)
+	}
+	sort.Strings(temps)
+	var shares []float64
+	total := 0.0
+	pickers := make(map[string]func(*rand.Rand) catalog.Item)
+	for _, temp := range temps {
+		var items []catalog.Item
+		for _, it := range c.Items {
+			if it.Temp == temp {
+				items = append(items, it)
+			}
+		}
+		if mix[temp] < 0 {
+			return nil, fmt.Errorf("negative share %g for %s orders", mix[temp], temp)
+		}
+		if len(items) == 0 && mix[temp] > 0 {
+			return nil, fmt.Errorf("catalog has no %s items", temp)
+		}
+		shares = append(shares, mix[temp])
+		total += mix[temp]
+		pickers[temp] = weighted(items)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("temperature mix has no positive share")
+	}
+	pickTemp := choice(shares)
+	return func(rng *rand.Rand) catalog.Item {
+		return pickers[temps[pickTemp(rng)]](rng)
+	}, nil
+}
+
+// weighted returns a function drawing items in proportion to their weights, or
+// uniformly if no item has a weight.
+func weighted(items []catalog.Item) func(*rand.Rand) catalog.Item {
+	weights := make([]float64, len(items))
+	for i, it := range items {
+		weights[i] = it.Weight
+	}
+	pick := choice(weights)
+	return func(rng *rand.Rand) catalog.Item {
+		return items[pick(rng)]
+	}
+}
+
+// choice returns a function drawing indexes in proportion to the given weights, or
+// uniformly if they are all zero.
+func choice(weights []float64) func(*rand.Rand) int {
+	cumulative := make([]float64, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+	}
+	return func(rng *rand.Rand) int {
+		if total == 0 {
+			return rng.Intn(len(weights))
+		}
+		x := rng.Float64() * total
+		return sort.Search(len(cumulative)-1, func(i int) bool { return cumulative[i] > x })
+	}
+}
+
+func randomID(rng *rand.Rand) string {
+	b := make([]byte, 5)
+	for i := range b {
+		b[i] = idAlphabet[rng.Intn(len(idAlphabet))]
+	}
+	return string(b)
+}
+
+// ParseMix parses a temperature mix such as "hot=0.4,cold=0.3,room=0.3".
+func ParseMix(value string) (map[string]float64, error) {
+	mix := make(map[string]float64)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		temp, share, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, want temperature=share", item)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(share), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid share for %s: %v", temp, err)
+		}
+		mix[strings.TrimSpace(temp)] = f
+	}
+	return mix, nil
+}
+
+// WriteJSON writes orders as an indented JSON array, the format served by the challenge server.
+func WriteJSON(w io.Writer, orders []GeneratedOrder) error {
+	data, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// WriteJSONL writes one order per line.
+func WriteJSONL(w io.Writer, orders []GeneratedOrder) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, o := range orders {
+		if err := enc.Encode(o); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
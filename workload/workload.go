@@ -0,0 +1,112 @@
+package workload
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"challenge/catalog"
+	css "challenge/client"
+	"challenge/entity"
+)
+
+// Load reads client orders from a JSON array or a JSONL file.
+func Load(path string) ([]css.Order, error) {
+	generated, err := LoadGenerated(path)
+	if err != nil {
+		return nil, err
+	}
+	return clientOrders(generated), nil
+}
+
+// Parse decodes client orders from a JSON array or from one JSON object per line.
+func Parse(data []byte) ([]css.Order, error) {
+	generated, err := ParseGenerated(data)
+	if err != nil {
+		return nil, err
+	}
+	return clientOrders(generated), nil
+}
+
+// LoadGenerated reads orders like Load, keeping the arrival and pickup times written by
+// the gen command.
+func LoadGenerated(path string) ([]GeneratedOrder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := ParseGenerated(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+	return orders, nil
+}
+
+// ParseGenerated decodes orders like Parse, keeping their arrival and pickup times.
+func ParseGenerated(data []byte) ([]GeneratedOrder, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var orders []GeneratedOrder
+		if err := json.Unmarshal(trimmed, &orders); err != nil {
+			return nil, err
+		}
+		return orders, nil
+	}
+	var orders []GeneratedOrder
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var o GeneratedOrder
+		if err := json.Unmarshal(text, &o); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, scanner.Err()
+}
+
+func clientOrders(generated []GeneratedOrder) []css.Order {
+	orders := make([]css.Order, 0, len(generated))
+	for _, o := range generated {
+		orders = append(orders, o.Order)
+	}
+	return orders
+}
+
+// ToOrder converts an order from the challenge client's type to our internal Order type.
+func ToOrder(o css.Order) entity.Order {
+	return entity.Order{
+		ID:               o.ID,
+		Name:             o.Name,
+		Temperature:      o.Temp,                                   // Assuming client's field is Temp.
+		Freshness:        time.Duration(o.Freshness) * time.Second, // Convert seconds to time.Duration.
+		InitialFreshness: time.Duration(o.Freshness) * time.Second,
+	}
+}
+
+// ToMenuOrder converts a client order, filling in defaults and the size and value from
+// its menu item. A non-nil error reports where the order disagrees with the menu; the
+// converted order is usable regardless.
+func ToMenuOrder(o css.Order, menu catalog.Catalog) (entity.Order, error) {
+	filled, item, err := menu.Fill(o)
+	order := ToOrder(filled)
+	order.Size, order.Value = item.Size, item.Value
+	return order, err
+}
+
+// ToOrders converts a list of client orders.
+func ToOrders(orders []css.Order) []entity.Order {
+	out := make([]entity.Order, 0, len(orders))
+	for _, o := range orders {
+		out = append(out, ToOrder(o))
+	}
+	return out
+}